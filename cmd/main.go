@@ -18,10 +18,23 @@ func init() {
 	subcommands.Register(subcommands.FlagsCommand(), "help")
 	subcommands.Register(subcommands.CommandsCommand(), "help")
 
+	subcommands.Register(&cfnSubcommands.StacksCmd{}, "")
 	subcommands.Register(&cfnSubcommands.ParametersCmd{}, "")
 	subcommands.Register(&cfnSubcommands.ResourcesCmd{}, "")
 	subcommands.Register(&cfnSubcommands.OutputsCmd{}, "")
+	subcommands.Register(&cfnSubcommands.TagsCmd{}, "")
+	subcommands.Register(&cfnSubcommands.ExportsCmd{}, "")
+	subcommands.Register(&cfnSubcommands.GraphCmd{}, "")
+	subcommands.Register(&cfnSubcommands.DriftCmd{}, "")
+	subcommands.Register(&cfnSubcommands.EventsCmd{}, "")
+	subcommands.Register(&cfnSubcommands.FailuresCmd{}, "")
+	subcommands.Register(&cfnSubcommands.ChangeSetsCmd{}, "")
+	subcommands.Register(&cfnSubcommands.TemplatesCmd{}, "")
+	subcommands.Register(&cfnSubcommands.TemplateDiffCmd{}, "")
+	subcommands.Register(&cfnSubcommands.TemplateVersionsCmd{}, "")
+	subcommands.Register(&cfnSubcommands.UnmanagedCmd{}, "")
 	subcommands.Register(&cfnSubcommands.AllCmd{}, "")
+	subcommands.Register(&cfnSubcommands.StackSetOperationsCmd{}, "")
 
 	flag.Parse()
 
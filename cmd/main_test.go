@@ -39,7 +39,7 @@ func TestMain_Parameters_valid_stdout_csv(t *testing.T) {
 	}
 	assert.Nil(t, err)
 
-	assert.Contains(t, string(out), "AccountId,AccountName,Region,StackName,ParameterName,ParameterType,ParameterDescription,ParameterDefaultValue,ParameterActualValue,Error")
+	assert.Contains(t, string(out), "AccountId,AccountName,Region,StackName,ParameterName,ParameterLabel,ParameterGroup,ParameterType,ParameterDescription,ParameterDefaultValue,ParameterActualValue,ParameterResolvedValue,ParameterProvenance,ParameterNoEcho,ParameterAllowedValues,ParameterConstraints,ParameterConstraintViolation,StackTemplateHash,Error")
 
 }
 
@@ -137,7 +137,7 @@ func TestMain_Resources_valid_stdout_csv(t *testing.T) {
 	}
 	assert.Nil(t, err)
 
-	assert.Contains(t, string(out), "AccountId,AccountName,Region,StackName,ResourcePhysicalId,ResourceLogicalId,ResourceType,ResourceDescription,ResourceStatus,ResourceDriftStatus,Error")
+	assert.Contains(t, string(out), "AccountId,AccountName,Region,StackName,ResourcePhysicalId,ResourceLogicalId,ResourceType,ResourceDescription,ResourceStatus,ResourceStatusReason,ResourceLastUpdatedTimestamp,ResourceModuleTypeHierarchy,ResourceModuleLogicalIdHierarchy,ResourceDriftStatus,ResourceDriftLastCheckTimestamp,StackTemplateHash,Error")
 
 }
 
@@ -190,7 +190,7 @@ func TestMain_Outputs_valid_stdout_csv(t *testing.T) {
 	}
 	assert.Nil(t, err)
 
-	assert.Contains(t, string(out), "AccountId,AccountName,Region,StackName,OutputName,OutputValue,OutputDescription,OutputExportName,Error")
+	assert.Contains(t, string(out), "AccountId,AccountName,Region,StackName,OutputName,OutputValue,OutputDescription,OutputExportName,StackTemplateHash,Error")
 
 }
 
@@ -219,3 +219,28 @@ func TestMain_Outputs_invalid_args(t *testing.T) {
 	assert.Contains(t, string(out), "required")
 
 }
+
+func TestMain_Stacks_valid_stdout_csv(t *testing.T) {
+	defer func() { os.Remove(TMP_OUT_PATH) }()
+	cmd := exec.Command("go", "run", "./main.go", "stacks", "-c", "../config/test_config.yaml")
+	out, err := cmd.Output()
+	if err != nil {
+		panic(err)
+	}
+	assert.Nil(t, err)
+
+	assert.Contains(t, string(out), "AccountId,AccountName,Region,StackName,StackId,StackStatus,StackStatusReason,StackCreationTime,StackLastUpdatedTime")
+
+}
+
+func TestMain_Stacks_invalid_args(t *testing.T) {
+	defer func() { os.Remove(TMP_OUT_PATH) }()
+	cmd := exec.Command("go", "run", "./main.go", "stacks")
+	out, err := cmd.Output()
+	if err != nil {
+		panic(err)
+	}
+	assert.Nil(t, err)
+	assert.Contains(t, string(out), "required")
+
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/gookit/config/v2"
@@ -41,9 +42,28 @@ type AccountConfig struct {
 	Filters    Filters
 }
 
+// TemplateMapping maps stacks matching both StackNameRegex and StackTags to a local template file.
+type TemplateMapping struct {
+	StackNameRegex string
+	StackTags      []Tag
+	// TemplatePath is relative to the config file unless it is an absolute path
+	TemplatePath string
+}
+
+// ResourceEnrichment adds properties at PropertyPaths of resources whose types match the Type glob,
+// got by Cloud Control API.
+type ResourceEnrichment struct {
+	Type          string
+	PropertyPaths []string
+}
+
 type CfnGlobalViewsConfig struct {
-	RootConfig     RootConfig
-	AccountConfigs []AccountConfig
+	RootConfig          RootConfig
+	AccountConfigs      []AccountConfig
+	TemplateMappings    []TemplateMapping
+	ResourceEnrichments []ResourceEnrichment
+	// UnmanagedResourceTypes are resource types listed by Cloud Control API to find resources no stack owns
+	UnmanagedResourceTypes []string
 }
 
 func init() {
@@ -51,7 +71,7 @@ func init() {
 	config.AddDriver(yamlv3.Driver)
 }
 
-func setDefaultConfig(config *CfnGlobalViewsConfig) error {
+func setDefaultConfig(config *CfnGlobalViewsConfig, configDir string) error {
 	err := []string{}
 	for i := range config.AccountConfigs {
 		// Credential.Type
@@ -77,6 +97,12 @@ func setDefaultConfig(config *CfnGlobalViewsConfig) error {
 			config.AccountConfigs[i].Filters.StackTags = config.RootConfig.Filters.StackTags
 		}
 	}
+	for i := range config.TemplateMappings {
+		// TemplateMappings.TemplatePath
+		if config.TemplateMappings[i].TemplatePath != "" && !filepath.IsAbs(config.TemplateMappings[i].TemplatePath) {
+			config.TemplateMappings[i].TemplatePath = filepath.Join(configDir, config.TemplateMappings[i].TemplatePath)
+		}
+	}
 	if len(err) == 0 {
 		return nil
 	} else {
@@ -102,6 +128,16 @@ func validate(config *CfnGlobalViewsConfig) error {
 			err = append(err, fmt.Sprintf("AccountConfigs[%v].Id is required", i))
 		}
 	}
+	for i, templateMapping := range config.TemplateMappings {
+		if templateMapping.TemplatePath == "" {
+			err = append(err, fmt.Sprintf("TemplateMappings[%v].TemplatePath is required", i))
+		}
+	}
+	for i, resourceEnrichment := range config.ResourceEnrichments {
+		if resourceEnrichment.Type == "" {
+			err = append(err, fmt.Sprintf("ResourceEnrichments[%v].Type is required", i))
+		}
+	}
 
 	if len(err) == 0 {
 		return nil
@@ -123,7 +159,7 @@ func GetConfig(filePath string) (*CfnGlobalViewsConfig, error) {
 		return CfnGlobalViewsConfig, err
 	}
 
-	setDefaultConfig(CfnGlobalViewsConfig)
+	setDefaultConfig(CfnGlobalViewsConfig, filepath.Dir(filePath))
 	err = validate(CfnGlobalViewsConfig)
 	if err != nil {
 		return CfnGlobalViewsConfig, err
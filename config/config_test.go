@@ -37,6 +37,17 @@ func TestConfig_valid(t *testing.T) {
 	assert.Equal(t, "prod", subAccount.Filters.StackTags[0].Value)
 	assert.Equal(t, "^CfnGlobalViews.*$", subAccount.Filters.StackNameRegex)
 
+	assert.Equal(t, 2, len(c.TemplateMappings))
+	assert.Equal(t, "templates/network.yaml", c.TemplateMappings[0].TemplatePath)
+	assert.Equal(t, "APP", c.TemplateMappings[1].StackTags[0].Key)
+	assert.Equal(t, "/path/to/templates/app.json", c.TemplateMappings[1].TemplatePath)
+
+	assert.Equal(t, 2, len(c.ResourceEnrichments))
+	assert.Equal(t, "AWS::RDS::DBInstance", c.ResourceEnrichments[0].Type)
+	assert.Equal(t, []string{"Engine", "EngineVersion"}, c.ResourceEnrichments[0].PropertyPaths)
+
+	assert.Equal(t, []string{"AWS::S3::Bucket", "AWS::EC2::SecurityGroup"}, c.UnmanagedResourceTypes)
+
 }
 
 const (
@@ -128,3 +139,58 @@ AccountConfigs:
 	assert.Contains(t, err.Error(), "AccountConfigs[0].Id is required", err.Error())
 
 }
+
+func TestConfig_invalid_template_mappings(t *testing.T) {
+	defer config.ClearAll()
+	defer func() { os.Remove(TMP_CONFIG_PATH) }()
+
+	tmpConfigYaml := `
+RootConfig:
+  Credential:
+    Type: "CLI"
+    ProfileName: default
+  Filters:
+    Regions:
+      - ap-northeast-1
+AccountConfigs:
+  - Name: main-account
+    Id: 382098889955
+TemplateMappings:
+  - StackNameRegex: "^network$"
+`
+	writeTmpYaml(tmpConfigYaml)
+
+	_, err := GetConfig(TMP_CONFIG_PATH)
+	assert.NotNil(t, err)
+
+	assert.Contains(t, err.Error(), "TemplateMappings[0].TemplatePath is required", err.Error())
+
+}
+
+func TestConfig_invalid_resource_enrichments(t *testing.T) {
+	defer config.ClearAll()
+	defer func() { os.Remove(TMP_CONFIG_PATH) }()
+
+	tmpConfigYaml := `
+RootConfig:
+  Credential:
+    Type: "CLI"
+    ProfileName: default
+  Filters:
+    Regions:
+      - ap-northeast-1
+AccountConfigs:
+  - Name: main-account
+    Id: 382098889955
+ResourceEnrichments:
+  - PropertyPaths:
+      - Engine
+`
+	writeTmpYaml(tmpConfigYaml)
+
+	_, err := GetConfig(TMP_CONFIG_PATH)
+	assert.NotNil(t, err)
+
+	assert.Contains(t, err.Error(), "ResourceEnrichments[0].Type is required", err.Error())
+
+}
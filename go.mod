@@ -2,22 +2,29 @@ module github.com/horietakehiro/cfn-global-views
 
 go 1.19
 
+require (
+	github.com/aws/aws-cdk-go/awscdk/v2 v2.65.0
+	github.com/aws/aws-sdk-go v1.44.203
+	github.com/aws/constructs-go/constructs/v10 v10.1.254
+	github.com/aws/jsii-runtime-go v1.75.0
+	github.com/google/subcommands v1.2.0
+	github.com/gookit/config/v2 v2.2.0
+	github.com/schollz/progressbar/v3 v3.13.0
+	github.com/stretchr/testify v1.8.1
+	github.com/xuri/excelize/v2 v2.7.0
+	golang.org/x/exp v0.0.0-20230213192124-5e25df0256eb
+	gopkg.in/yaml.v3 v3.0.1
+)
+
 require (
 	github.com/Masterminds/semver/v3 v3.2.0 // indirect
-	github.com/aws/aws-cdk-go/awscdk/v2 v2.65.0 // indirect
-	github.com/aws/aws-sdk-go v1.44.203 // indirect
-	github.com/aws/constructs-go/constructs/v10 v10.1.254 // indirect
-	github.com/aws/jsii-runtime-go v1.75.0 // indirect
 	github.com/cdklabs/awscdk-asset-awscli-go/awscliv1/v2 v2.2.66 // indirect
 	github.com/cdklabs/awscdk-asset-kubectl-go/kubectlv20/v2 v2.1.1 // indirect
 	github.com/cdklabs/awscdk-asset-node-proxy-agent-go/nodeproxyagentv5/v2 v2.0.55 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/fatih/color v1.14.1 // indirect
-	github.com/gocarina/gocsv v0.0.0-20230123225133-763e25b40669 // indirect
 	github.com/goccy/go-yaml v1.9.8 // indirect
-	github.com/google/subcommands v1.2.0 // indirect
 	github.com/gookit/color v1.5.2 // indirect
-	github.com/gookit/config/v2 v2.2.0 // indirect
 	github.com/gookit/goutil v0.6.4 // indirect
 	github.com/imdario/mergo v0.3.13 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
@@ -31,15 +38,11 @@ require (
 	github.com/richardlehane/mscfb v1.0.4 // indirect
 	github.com/richardlehane/msoleps v1.0.3 // indirect
 	github.com/rivo/uniseg v0.4.3 // indirect
-	github.com/schollz/progressbar/v3 v3.13.0 // indirect
-	github.com/stretchr/testify v1.8.1 // indirect
 	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
 	github.com/xuri/efp v0.0.0-20220603152613-6918739fd470 // indirect
-	github.com/xuri/excelize/v2 v2.7.0 // indirect
 	github.com/xuri/nfp v0.0.0-20220409054826-5e722a1d9e22 // indirect
 	github.com/yuin/goldmark v1.4.13 // indirect
 	golang.org/x/crypto v0.5.0 // indirect
-	golang.org/x/exp v0.0.0-20230213192124-5e25df0256eb // indirect
 	golang.org/x/lint v0.0.0-20210508222113-6edffad5e616 // indirect
 	golang.org/x/mod v0.7.0 // indirect
 	golang.org/x/net v0.5.0 // indirect
@@ -49,5 +52,4 @@ require (
 	golang.org/x/text v0.7.0 // indirect
 	golang.org/x/tools v0.5.0 // indirect
 	golang.org/x/xerrors v0.0.0-20220907171357-04be3eba64a2 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -4,12 +4,13 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"io"
 	"os"
 
 	"github.com/google/subcommands"
-	"github.com/horietakehiro/cfn-global-views/config"
 	"golang.org/x/exp/slog"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
 )
 
 type AllCmd struct {
@@ -18,6 +19,7 @@ type AllCmd struct {
 	outFilePath    string
 	format         string
 	verbose        bool
+	tagColumns     []string
 	logger         *slog.Logger
 	config         *config.CfnGlobalViewsConfig
 }
@@ -26,150 +28,112 @@ func (*AllCmd) Name() string {
 	return "all"
 }
 func (*AllCmd) Synopsis() string {
-	return "list cfn parameters, resources, outputs"
+	return "list cfn stacks, parameters, resources, outputs"
 }
 func (*AllCmd) Usage() string {
-	return "all -c path/to/config.yaml -o outfile.xlsx"
+	return "all -c path/to/config.yaml [-f excel -o outfile.xlsx]"
 }
 func (c *AllCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
 	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
-	f.StringVar(&c.format, "f", "excel", "output data format [excel] (default is excel)")
+	f.StringVar(&c.format, "f", "excel", formatUsage("excel"))
 	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+	f.Var((*listFlag)(&c.tagColumns), "tag-columns", "comma separated stack tag keys to add as columns to parameters, resources and outputs sheets e.g. ENV,Owner,CostCenter")
 }
 
 func (c *AllCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	var err error
-	result := subcommands.ExitFailure
-	if c.configFilePath == "" {
-		fmt.Println("arg '-c path/to/config.yaml' is required")
-		return result
+
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return subcommands.ExitFailure
 	}
-	if c.format != "excel" {
-		fmt.Println("allowed values for arg '-f' is [excel]")
-		return result
+
+	if c.outFilePath != "" {
+		if _, err := os.Stat(c.outFilePath); err == nil {
+			os.Rename(c.outFilePath, c.outFilePath+".bak")
+		}
 	}
-	if c.format == "excel" && c.outFilePath == "" {
-		fmt.Println("if format is excel, must specify output file path arg '-o'")
-		return result
+	err = c.write()
+	if c.outFilePath != "" {
+		if err != nil {
+			os.Rename(c.outFilePath+".bak", c.outFilePath)
+		} else {
+			os.Remove(c.outFilePath + ".bak")
+		}
 	}
+	return exitStatus(c.logger, err)
+}
 
-	if c.verbose {
-		c.logger = slog.New(slog.NewJSONHandler(os.Stdout))
-	} else {
-		c.logger = slog.New(slog.NewJSONHandler(io.Discard))
+// write writes the combined report in the format. if the format is streamed,
+// views of each account and region are written as soon as they are collected.
+func (c *AllCmd) write() error {
+	writer, ok := output.Lookup(c.format)
+	if !ok {
+		return fmt.Errorf("output format %s is not registered", c.format)
+	}
+	streamWriter, ok := writer.(output.StreamWriter)
+	if !ok {
+		return writer.Write(c.outFilePath, c.Report(nil))
 	}
 
-	c.config, err = config.GetConfig(c.configFilePath)
+	stream, err := streamWriter.OpenStream(c.outFilePath)
 	if err != nil {
-		fmt.Println(err.Error())
-		return result
+		return err
 	}
+	c.Report(stream)
+	return stream.Close()
+}
 
+// Report combines tables of stacks, parameters, resources and outputs,
+// all of which are written even by single table formats.
+// if stream is not nil, views of each account and region are also written to it as soon as they are collected.
+func (c *AllCmd) Report(stream output.Stream) *output.Report {
+	templates := newTemplateCache()
+	stacksCmd := StacksCmd{
+		verbose:   c.verbose,
+		logger:    c.logger,
+		config:    c.config,
+		templates: templates,
+	}
 	parametersCmd := ParametersCmd{
-		configFilePath: c.configFilePath,
-		outFilePath:    c.outFilePath,
-		format:         c.format,
-		verbose:        c.verbose,
-		logger:         c.logger,
-		config:         c.config,
+		verbose:    c.verbose,
+		tagColumns: c.tagColumns,
+		logger:     c.logger,
+		config:     c.config,
+		templates:  templates,
 	}
 	resourcesCmd := ResourcesCmd{
-		configFilePath: c.configFilePath,
-		outFilePath:    c.outFilePath,
-		format:         c.format,
-		verbose:        c.verbose,
-		logger:         c.logger,
-		config:         c.config,
+		verbose:    c.verbose,
+		tagColumns: c.tagColumns,
+		logger:     c.logger,
+		config:     c.config,
+		templates:  templates,
 	}
 	outputsCmd := OutputsCmd{
-		configFilePath: c.configFilePath,
-		outFilePath:    c.outFilePath,
-		format:         c.format,
-		verbose:        c.verbose,
-		logger:         c.logger,
-		config:         c.config,
+		verbose:    c.verbose,
+		tagColumns: c.tagColumns,
+		logger:     c.logger,
+		config:     c.config,
+		templates:  templates,
 	}
 
-	if _, err := os.Stat(c.outFilePath); err == nil {
-		os.Rename(c.outFilePath, c.outFilePath+".bak")
+	if stream != nil {
+		stacksCmd.collected = streamPart(stream, stacksCmd.Report)
+		parametersCmd.collected = streamPart(stream, parametersCmd.Report)
+		resourcesCmd.collected = streamPart(stream, resourcesCmd.Report)
+		outputsCmd.collected = streamPart(stream, outputsCmd.Report)
 	}
-	defer func() {
-		if result == subcommands.ExitFailure {
-			os.Rename(c.outFilePath+".bak", c.outFilePath)
-		} else {
-			os.Remove(c.outFilePath + ".bak")
-		}
-	}()
 
-	result = parametersCmd.Execute(context.TODO(), f, nil)
-	if result == subcommands.ExitFailure {
-		return result
-	}
-	resourcesCmd.Execute(context.TODO(), f, nil)
-	if result == subcommands.ExitFailure {
-		return result
+	report := &output.Report{Main: output.ALL_TABLES}
+	for _, r := range []*output.Report{
+		stacksCmd.Report(stacksCmd.GetGlobalViews()),
+		parametersCmd.Report(parametersCmd.GetGlobalViews()),
+		resourcesCmd.Report(resourcesCmd.GetGlobalViews()),
+		outputsCmd.Report(outputsCmd.GetGlobalViews()),
+	} {
+		report.Tables = append(report.Tables, r.Tables...)
 	}
-	outputsCmd.Execute(context.TODO(), f, nil)
-	if result == subcommands.ExitFailure {
-		return result
-	}
-	// var parametersViews []*CfnParametersView
-	// var resourcesViews []*CfnResourcesView
-	// var outputsViews []*CfnOutputsView
-
-	// parametersChannel := make(chan []*CfnParametersView)
-	// resourcesChannel := make(chan []*CfnResourcesView)
-	// outputsChannel := make(chan []*CfnOutputsView)
-	// done := make(chan interface{})
-
-	// 	go func(ch chan []*CfnParametersView) {
-	// 		views := parametersCmd.GetGlobalViews()
-	// 		ch <- views
-	// 		// close(ch)
-	// 	}(parametersChannel)
-	// 	go func(ch chan []*CfnResourcesView) {
-	// 		views := resourcesCmd.GetGlobalViews()
-	// 		ch <- views
-	// 		// close(ch)
-	// 	}(resourcesChannel)
-	// 	go func(ch chan []*CfnOutputsView) {
-	// 		views := outputsCmd.GetGlobalViews()
-	// 		ch <- views
-	// 		// close(ch)
-	// 	}(outputsChannel)
-
-	// breakPoint:
-	// 	for {
-	// 		select {
-	// 		case parametersViews = <-parametersChannel:
-	// 			c.logger.Info(fmt.Sprintf("parameters %v", len(parametersViews)))
-	// 			err := parametersCmd.DumpExcel(parametersViews)
-	// 			if err != nil {
-	// 				c.logger.Error(err.Error(), err)
-	// 			}
-	// 		case resourcesViews = <-resourcesChannel:
-	// 			c.logger.Info(fmt.Sprintf("resources %v", len(resourcesViews)))
-	// 			err := resourcesCmd.DumpExcel(resourcesViews)
-	// 			if err != nil {
-	// 				c.logger.Error(err.Error(), err)
-	// 			}
-	// 		case outputsViews = <-outputsChannel:
-	// 			c.logger.Info(fmt.Sprintf("outputs %v", len(outputsViews)))
-	// 			err := outputsCmd.DumpExcel(outputsViews)
-	// 			if err != nil {
-	// 				c.logger.Error(err.Error(), err)
-	// 			}
-	// 		case <-done:
-	// 			break breakPoint
-
-	// 		default:
-	// 			if len(parametersViews) != 0 && len(resourcesViews) != 0 && len(outputsViews) != 0 {
-	// 				close(done)
-	// 			}
-	// 		}
-	// 	}
-
-	return result
-
+	return report
 }
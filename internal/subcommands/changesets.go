@@ -0,0 +1,334 @@
+package subcommands
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/cloudtrail/cloudtrailiface"
+	"github.com/google/subcommands"
+	"golang.org/x/exp/slog"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+const (
+	CHANGESET_ROW_TYPE_SUMMARY = "summary"
+	CHANGESET_ROW_TYPE_CHANGE  = "change"
+)
+
+type CfnResourceChange struct {
+	Action      string
+	LogicalId   string
+	PhysicalId  string
+	Type        string
+	Replacement string
+	Scope       []string
+}
+
+type CfnChangeSet struct {
+	Name            string
+	Id              string
+	Status          string
+	StatusReason    string
+	ExecutionStatus string
+	CreationTime    string
+	// Creator is looked up from CloudTrail only if -lookup-creator is set
+	Creator     string
+	Description string
+	Changes     []CfnResourceChange
+}
+
+type CfnChangeSetsView struct {
+	AccountId   string
+	AccountName string
+	Region      string
+	StackName   string
+	ChangeSets  []CfnChangeSet
+	Error       error
+}
+
+type CfnChangeSetsCsvView struct {
+	AccountId                string
+	AccountName              string
+	Region                   string
+	StackName                string
+	RowType                  string
+	ChangeSetName            string
+	ChangeSetStatus          string
+	ChangeSetStatusReason    string
+	ChangeSetExecutionStatus string
+	ChangeSetCreationTime    string
+	ChangeSetCreator         string
+	ChangeSetDescription     string
+	ChangeSetChangeCount     string
+	ChangeAction             string
+	ChangeLogicalId          string
+	ChangePhysicalId         string
+	ChangeResourceType       string
+	ChangeReplacement        string
+	ChangeScope              string
+	Error                    string
+}
+
+type ChangeSetsCmd struct {
+	subcommands.Command
+	configFilePath string
+	outFilePath    string
+	format         string
+	verbose        bool
+	lookupCreator  bool
+	logger         *slog.Logger
+	config         *config.CfnGlobalViewsConfig
+	collected      func(views []*CfnChangeSetsView)
+}
+
+func (*ChangeSetsCmd) Name() string {
+	return "changesets"
+}
+func (*ChangeSetsCmd) Synopsis() string {
+	return "list cfn change sets and their proposed changes"
+}
+func (*ChangeSetsCmd) Usage() string {
+	return "changesets -c path/to/config.yaml [-lookup-creator]"
+}
+func (c *ChangeSetsCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
+	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
+	f.StringVar(&c.format, "f", "csv", formatUsage("csv"))
+	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+	f.BoolVar(&c.lookupCreator, "lookup-creator", false, "if set, look up creators of change sets from CloudTrail events within the last 90 days")
+}
+
+func (c *ChangeSetsCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return subcommands.ExitFailure
+	}
+
+	return exitStatus(c.logger, writeGlobalViews(c.format, c.outFilePath, &c.collected, c.GetGlobalViews, c.Report))
+}
+
+// toCsvViews lists a summary row for each change set followed by a row for each of its changes.
+func (c *ChangeSetsCmd) toCsvViews(views []*CfnChangeSetsView) []CfnChangeSetsCsvView {
+	csvViews := []CfnChangeSetsCsvView{}
+
+	for _, view := range views {
+		base := CfnChangeSetsCsvView{
+			AccountId:   view.AccountId,
+			AccountName: view.AccountName,
+			Region:      view.Region,
+			StackName:   view.StackName,
+			Error:       errorString(view.Error),
+		}
+		if len(view.ChangeSets) == 0 {
+			csvViews = append(csvViews, base)
+		}
+		for _, changeSet := range view.ChangeSets {
+			summary := base
+			summary.RowType = CHANGESET_ROW_TYPE_SUMMARY
+			summary.ChangeSetName = changeSet.Name
+			summary.ChangeSetStatus = changeSet.Status
+			summary.ChangeSetStatusReason = changeSet.StatusReason
+			summary.ChangeSetExecutionStatus = changeSet.ExecutionStatus
+			summary.ChangeSetCreationTime = changeSet.CreationTime
+			summary.ChangeSetCreator = changeSet.Creator
+			summary.ChangeSetDescription = changeSet.Description
+			summary.ChangeSetChangeCount = strconv.Itoa(len(changeSet.Changes))
+			csvViews = append(csvViews, summary)
+
+			for _, change := range changeSet.Changes {
+				csvViews = append(csvViews, CfnChangeSetsCsvView{
+					AccountId:          view.AccountId,
+					AccountName:        view.AccountName,
+					Region:             view.Region,
+					StackName:          view.StackName,
+					RowType:            CHANGESET_ROW_TYPE_CHANGE,
+					ChangeSetName:      changeSet.Name,
+					ChangeAction:       change.Action,
+					ChangeLogicalId:    change.LogicalId,
+					ChangePhysicalId:   change.PhysicalId,
+					ChangeResourceType: change.Type,
+					ChangeReplacement:  change.Replacement,
+					ChangeScope:        strings.Join(change.Scope, ","),
+					Error:              errorString(view.Error),
+				})
+			}
+		}
+	}
+
+	return csvViews
+}
+
+func (c *ChangeSetsCmd) Report(views []*CfnChangeSetsView) *output.Report {
+	return &output.Report{Tables: []*output.Table{newTable(c.toCsvViews(views)).toOutput(c.Name(), views)}}
+}
+
+func (c *ChangeSetsCmd) GetGlobalViews() []*CfnChangeSetsView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, c.collected)
+
+	sortViews(globalViews, func(view *CfnChangeSetsView) []string {
+		return []string{view.AccountId, view.Region, view.StackName}
+	})
+
+	return globalViews
+}
+
+func (c *ChangeSetsCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnChangeSetsView {
+	views := []*CfnChangeSetsView{}
+	c.logger.Info("get cfn change sets", "accountId", accountConfig.Id, "region", region)
+
+	sess := newSession(accountConfig, region)
+	cfn := cloudformation.New(sess)
+	var trail cloudtrailiface.CloudTrailAPI
+	if c.lookupCreator {
+		trail = cloudtrail.New(sess)
+	}
+
+	matchedStacks, err := describeMatchedStacks(cfn, accountConfig.Filters)
+	if err != nil {
+		return append(views, &CfnChangeSetsView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Error:       err,
+		})
+	}
+
+	for _, matchedStack := range matchedStacks {
+		changeSets, err := c.describeChangeSets(cfn, trail, *matchedStack.StackId)
+		if err == nil && len(changeSets) == 0 {
+			continue
+		}
+		views = append(views, &CfnChangeSetsView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			StackName:   *matchedStack.StackName,
+			ChangeSets:  changeSets,
+			Error:       err,
+		})
+	}
+
+	return views
+}
+
+// describeChangeSets returns all change sets of the stack with their changes.
+// creators are looked up only if trail is not nil.
+func (c *ChangeSetsCmd) describeChangeSets(cfn cloudformationiface.CloudFormationAPI, trail cloudtrailiface.CloudTrailAPI, stackId string) ([]CfnChangeSet, error) {
+	changeSets := []CfnChangeSet{}
+	input := &cloudformation.ListChangeSetsInput{
+		StackName: aws.String(stackId),
+	}
+	for {
+		listOutput, err := cfn.ListChangeSets(input)
+		if err != nil {
+			return changeSets, err
+		}
+		for _, summary := range listOutput.Summaries {
+			changeSet := CfnChangeSet{
+				Name:            aws.StringValue(summary.ChangeSetName),
+				Id:              aws.StringValue(summary.ChangeSetId),
+				Status:          aws.StringValue(summary.Status),
+				StatusReason:    aws.StringValue(summary.StatusReason),
+				ExecutionStatus: aws.StringValue(summary.ExecutionStatus),
+				CreationTime:    formatTime(summary.CreationTime),
+				Description:     aws.StringValue(summary.Description),
+			}
+			changeSet.Changes, err = describeChanges(cfn, changeSet.Id)
+			if err != nil {
+				return changeSets, err
+			}
+			if trail != nil && summary.CreationTime != nil {
+				changeSet.Creator = lookupChangeSetCreator(trail, changeSet.Id, *summary.CreationTime)
+			}
+			changeSets = append(changeSets, changeSet)
+		}
+		if listOutput.NextToken == nil {
+			break
+		}
+		input.NextToken = listOutput.NextToken
+	}
+	return changeSets, nil
+}
+
+func describeChanges(cfn cloudformationiface.CloudFormationAPI, changeSetId string) ([]CfnResourceChange, error) {
+	changes := []CfnResourceChange{}
+	input := &cloudformation.DescribeChangeSetInput{
+		ChangeSetName: aws.String(changeSetId),
+	}
+	for {
+		describeOutput, err := cfn.DescribeChangeSet(input)
+		if err != nil {
+			return changes, err
+		}
+		for _, change := range describeOutput.Changes {
+			resourceChange := change.ResourceChange
+			if resourceChange == nil {
+				continue
+			}
+			changes = append(changes, CfnResourceChange{
+				Action:      aws.StringValue(resourceChange.Action),
+				LogicalId:   aws.StringValue(resourceChange.LogicalResourceId),
+				PhysicalId:  aws.StringValue(resourceChange.PhysicalResourceId),
+				Type:        aws.StringValue(resourceChange.ResourceType),
+				Replacement: aws.StringValue(resourceChange.Replacement),
+				Scope:       aws.StringValueSlice(resourceChange.Scope),
+			})
+		}
+		if describeOutput.NextToken == nil {
+			break
+		}
+		input.NextToken = describeOutput.NextToken
+	}
+	return changes, nil
+}
+
+// lookupChangeSetCreator returns the user name of the CreateChangeSet CloudTrail event of the change set.
+// it returns an empty string if the event is not found, e.g. older than 90 days or not permitted to look up.
+func lookupChangeSetCreator(trail cloudtrailiface.CloudTrailAPI, changeSetId string, creationTime time.Time) string {
+	input := &cloudtrail.LookupEventsInput{
+		LookupAttributes: []*cloudtrail.LookupAttribute{{
+			AttributeKey:   aws.String(cloudtrail.LookupAttributeKeyEventName),
+			AttributeValue: aws.String("CreateChangeSet"),
+		}},
+		StartTime: aws.Time(creationTime.Add(-5 * time.Minute)),
+		EndTime:   aws.Time(creationTime.Add(5 * time.Minute)),
+	}
+	for {
+		lookupOutput, err := trail.LookupEvents(input)
+		if err != nil {
+			return ""
+		}
+		for _, event := range lookupOutput.Events {
+			var trailEvent struct {
+				ResponseElements struct {
+					Id string `json:"id"`
+				} `json:"responseElements"`
+			}
+			if json.Unmarshal([]byte(aws.StringValue(event.CloudTrailEvent)), &trailEvent) != nil {
+				continue
+			}
+			if trailEvent.ResponseElements.Id == changeSetId {
+				return aws.StringValue(event.Username)
+			}
+		}
+		if lookupOutput.NextToken == nil {
+			break
+		}
+		input.NextToken = lookupOutput.NextToken
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package subcommands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/cloudtrail/cloudtrailiface"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockChangeSetsClient struct {
+	cloudformationiface.CloudFormationAPI
+}
+
+func (m *mockChangeSetsClient) ListChangeSets(input *cloudformation.ListChangeSetsInput) (*cloudformation.ListChangeSetsOutput, error) {
+	return &cloudformation.ListChangeSetsOutput{Summaries: []*cloudformation.ChangeSetSummary{{
+		ChangeSetName:   aws.String("add-queue"),
+		ChangeSetId:     aws.String("changeset-id"),
+		Status:          aws.String("CREATE_COMPLETE"),
+		ExecutionStatus: aws.String("AVAILABLE"),
+		CreationTime:    aws.Time(time.Now()),
+	}}}, nil
+}
+
+func (m *mockChangeSetsClient) DescribeChangeSet(input *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+	if input.NextToken == nil {
+		return &cloudformation.DescribeChangeSetOutput{
+			Changes: []*cloudformation.Change{{ResourceChange: &cloudformation.ResourceChange{
+				Action:            aws.String("Add"),
+				LogicalResourceId: aws.String("Queue"),
+				ResourceType:      aws.String("AWS::SQS::Queue"),
+			}}},
+			NextToken: aws.String("next"),
+		}, nil
+	}
+	return &cloudformation.DescribeChangeSetOutput{
+		Changes: []*cloudformation.Change{{ResourceChange: &cloudformation.ResourceChange{
+			Action:            aws.String("Modify"),
+			LogicalResourceId: aws.String("Bucket"),
+			ResourceType:      aws.String("AWS::S3::Bucket"),
+			Replacement:       aws.String("True"),
+			Scope:             aws.StringSlice([]string{"Properties", "Tags"}),
+		}}},
+	}, nil
+}
+
+type mockTrailClient struct {
+	cloudtrailiface.CloudTrailAPI
+}
+
+func (m *mockTrailClient) LookupEvents(input *cloudtrail.LookupEventsInput) (*cloudtrail.LookupEventsOutput, error) {
+	return &cloudtrail.LookupEventsOutput{Events: []*cloudtrail.Event{
+		{Username: aws.String("someone-else"), CloudTrailEvent: aws.String(`{"responseElements":{"id":"another-id"}}`)},
+		{Username: aws.String("creator"), CloudTrailEvent: aws.String(`{"responseElements":{"id":"changeset-id"}}`)},
+	}}, nil
+}
+
+func TestChangeSets_invalid(t *testing.T) {
+	c := getInvalidConfig(t, "")
+
+	cmd := ChangeSetsCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 1, len(views))
+	assert.NotNil(t, views[0].Error, views[0])
+
+}
+
+func TestChangeSets_describeChangeSets(t *testing.T) {
+	cmd := ChangeSetsCmd{}
+	changeSets, err := cmd.describeChangeSets(&mockChangeSetsClient{}, &mockTrailClient{}, "stack")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(changeSets))
+	assert.Equal(t, "creator", changeSets[0].Creator)
+	assert.Equal(t, 2, len(changeSets[0].Changes))
+	assert.Equal(t, []string{"Properties", "Tags"}, changeSets[0].Changes[1].Scope)
+
+	csvViews := cmd.toCsvViews([]*CfnChangeSetsView{{StackName: "stack", ChangeSets: changeSets}})
+	assert.Equal(t, 3, len(csvViews))
+	assert.Equal(t, CHANGESET_ROW_TYPE_SUMMARY, csvViews[0].RowType)
+	assert.Equal(t, "2", csvViews[0].ChangeSetChangeCount)
+	assert.Equal(t, CHANGESET_ROW_TYPE_CHANGE, csvViews[2].RowType)
+	assert.Equal(t, "Properties,Tags", csvViews[2].ChangeScope)
+
+	changeSets, err = cmd.describeChangeSets(&mockChangeSetsClient{}, nil, "stack")
+	assert.Nil(t, err)
+	assert.Equal(t, "", changeSets[0].Creator)
+}
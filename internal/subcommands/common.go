@@ -0,0 +1,467 @@
+package subcommands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/google/subcommands"
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/exp/slog"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+const (
+	TIME_FORMAT = time.RFC3339
+)
+
+func newLogger(verbose bool) *slog.Logger {
+	if verbose {
+		return slog.New(slog.HandlerOptions{Level: slog.LevelDebug}.NewJSONHandler(os.Stdout))
+	}
+	return slog.New(slog.NewJSONHandler(io.Discard))
+}
+
+func newSession(accountConfig config.AccountConfig, region string) *session.Session {
+	if accountConfig.Credential.Type == config.CRED_TYPE_CLI {
+		return session.Must(session.NewSessionWithOptions(session.Options{
+			Profile: accountConfig.Credential.ProfileName,
+			Config:  *aws.NewConfig().WithRegion(region),
+		}))
+	}
+	return session.Must(session.NewSessionWithOptions(session.Options{
+		Config: *aws.NewConfig().WithRegion(region),
+	}))
+}
+
+// collectGlobalViews calls collect for every account and region in the config concurrently,
+// and returns all collected views in no particular order.
+// if collected is not nil, it's called with views of each account and region as soon as they are collected.
+func collectGlobalViews[V any](c *config.CfnGlobalViewsConfig, verbose bool, collect func(accountConfig config.AccountConfig, region string) []V, collected func(views []V)) []V {
+	globalViews := []V{}
+
+	totalViews := 0
+	for _, accountConfig := range c.AccountConfigs {
+		totalViews += len(accountConfig.Filters.Regions)
+	}
+
+	var bar *progressbar.ProgressBar
+	if !verbose {
+		bar = progressbar.Default(int64(totalViews))
+	}
+
+	channel := make(chan []V, totalViews)
+	wg := sync.WaitGroup{}
+	for _, accountConfig := range c.AccountConfigs {
+		for _, region := range accountConfig.Filters.Regions {
+			wg.Add(1)
+			go func(accountConfig config.AccountConfig, region string) {
+				defer wg.Done()
+				views := collect(accountConfig, region)
+				if collected != nil {
+					collected(views)
+				}
+				channel <- views
+				if bar != nil {
+					bar.Add(1)
+				}
+			}(accountConfig, region)
+		}
+	}
+	wg.Wait()
+	close(channel)
+
+	for views := range channel {
+		globalViews = append(globalViews, views...)
+	}
+	return globalViews
+}
+
+// sortViews stably sorts views by their keys, e.g. account id, region and stack name, compared in order.
+func sortViews[V any](views []V, keys func(view V) []string) {
+	sort.SliceStable(views, func(i, j int) bool {
+		ki, kj := keys(views[i]), keys(views[j])
+		for k := range ki {
+			if ki[k] != kj[k] {
+				return ki[k] < kj[k]
+			}
+		}
+		return false
+	})
+}
+
+// describeMatchedStacks describes all stacks at the client's account and region,
+// and returns those matching both StackNameRegex and StackTags of the filters.
+func describeMatchedStacks(cfn cloudformationiface.CloudFormationAPI, filters config.Filters) ([]*cloudformation.Stack, error) {
+	var matchedStacks []*cloudformation.Stack
+	input := &cloudformation.DescribeStacksInput{}
+	for {
+		describeStacksOutput, err := cfn.DescribeStacks(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, stack := range describeStacksOutput.Stacks {
+			if matchesFilters(stack, filters) {
+				matchedStacks = append(matchedStacks, stack)
+			}
+		}
+		if describeStacksOutput.NextToken == nil {
+			break
+		}
+		input.NextToken = describeStacksOutput.NextToken
+	}
+	return matchedStacks, nil
+}
+
+func matchesFilters(stack *cloudformation.Stack, filters config.Filters) bool {
+	matched, _ := regexp.MatchString(filters.StackNameRegex, *stack.StackName)
+	return matched && hasAllTags(stack.Tags, filters.StackTags)
+}
+
+func hasAllTags(stackTags []*cloudformation.Tag, filterTags []config.Tag) bool {
+	for _, filterTag := range filterTags {
+		found := false
+		for _, stackTag := range stackTags {
+			if filterTag.Key == *stackTag.Key && filterTag.Value == *stackTag.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// parseSince parses a time window such as "30m", "2h" or "7d" (a day suffix is allowed in addition to
+// time.ParseDuration units) and returns the start time of the window. an empty string means no window.
+func parseSince(since string) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+	var d time.Duration
+	var err error
+	if strings.HasSuffix(since, "d") {
+		n, convErr := strconv.Atoi(strings.TrimSuffix(since, "d"))
+		if convErr != nil {
+			return time.Time{}, fmt.Errorf("invalid time window %q", since)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(since)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time window %q", since)
+		}
+	}
+	return time.Now().Add(-d), nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(TIME_FORMAT)
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// table is a header and records to be converted into an output table.
+type table struct {
+	header  []string
+	records [][]interface{}
+	// highlights are cells filled in excel, keyed by record and column indexes
+	highlights map[[2]int]bool
+	// links are names of columns whose values are urls, written as hyperlinks in excel
+	links map[string]bool
+}
+
+// newTable converts rows, a slice of flat structs, into a table whose header is the struct field names.
+func newTable(rows interface{}) *table {
+	rowsValue := reflect.ValueOf(rows)
+	rowType := rowsValue.Type().Elem()
+
+	t := &table{}
+	for i := 0; i < rowType.NumField(); i++ {
+		t.header = append(t.header, rowType.Field(i).Name)
+	}
+	for r := 0; r < rowsValue.Len(); r++ {
+		record := []interface{}{}
+		for i := 0; i < rowType.NumField(); i++ {
+			record = append(record, rowsValue.Index(r).Field(i).Interface())
+		}
+		t.records = append(t.records, record)
+	}
+	return t
+}
+
+func (t *table) highlight(r, i int) {
+	if t.highlights == nil {
+		t.highlights = map[[2]int]bool{}
+	}
+	t.highlights[[2]int{r, i}] = true
+}
+
+func (t *table) link(columns ...string) {
+	if t.links == nil {
+		t.links = map[string]bool{}
+	}
+	for _, column := range columns {
+		t.links[column] = true
+	}
+}
+
+// toOutput converts the table into an output table named name, whose structured view is data.
+func (t *table) toOutput(name string, data interface{}) *output.Table {
+	return &output.Table{
+		Name:       name,
+		Header:     t.header,
+		Records:    t.records,
+		Highlights: t.highlights,
+		Links:      t.links,
+		Data:       data,
+	}
+}
+
+// insertColumns inserts columns right after the column named after.
+// values[r][i] is the value of the r-th record at the i-th inserted column.
+func (t *table) insertColumns(after string, columns []string, values [][]string) {
+	at := len(t.header)
+	for i, name := range t.header {
+		if name == after {
+			at = i + 1
+		}
+	}
+
+	header := append([]string{}, t.header[:at]...)
+	header = append(header, columns...)
+	t.header = append(header, t.header[at:]...)
+
+	for r := range t.records {
+		record := append([]interface{}{}, t.records[r][:at]...)
+		for i := range columns {
+			record = append(record, values[r][i])
+		}
+		t.records[r] = append(record, t.records[r][at:]...)
+	}
+
+	highlights := t.highlights
+	t.highlights = nil
+	for cell := range highlights {
+		if cell[1] >= at {
+			cell[1] += len(columns)
+		}
+		t.highlight(cell[0], cell[1])
+	}
+}
+
+// insertTagColumns inserts a column per tag key right after the StackName column.
+// rowTags[r] is the stack tags of the r-th record, and missing tags are left empty.
+func (t *table) insertTagColumns(tagKeys []string, rowTags [][]CfnTag) {
+	if len(tagKeys) == 0 {
+		return
+	}
+	columns := []string{}
+	for _, key := range tagKeys {
+		columns = append(columns, fmt.Sprintf("Tag:%s", key))
+	}
+	values := [][]string{}
+	for _, tags := range rowTags {
+		values = append(values, tagValues(tags, tagKeys))
+	}
+	t.insertColumns("StackName", columns, values)
+}
+
+// validateFormat checks the common '-c', '-f' and '-o' args of subcommands.
+func validateFormat(configFilePath, format, outFilePath string, allowedFormats []string) error {
+	if configFilePath == "" {
+		return fmt.Errorf("arg '-c path/to/config.yaml' is required")
+	}
+	allowed := false
+	for _, f := range allowedFormats {
+		if f == format {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("allowed values for arg '-f' are [%s]", strings.Join(allowedFormats, ", "))
+	}
+	if writer, ok := output.Lookup(format); ok && writer.RequiresFile() && outFilePath == "" {
+		return fmt.Errorf("if format is %s, must specify output file path arg '-o'", format)
+	}
+	return nil
+}
+
+// setupCmd is the common start of Execute of subcommands. it checks the common '-c', '-f' and '-o' args,
+// then the subcommand specific args by validate if not nil, and returns the logger and the config.
+func setupCmd(configFilePath, format, outFilePath string, allowedFormats []string, verbose bool, validate func() error) (*slog.Logger, *config.CfnGlobalViewsConfig, error) {
+	err := validateFormat(configFilePath, format, outFilePath, allowedFormats)
+	if err != nil {
+		return nil, nil, err
+	}
+	if validate != nil {
+		if err := validate(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	logger := newLogger(verbose)
+
+	c, err := config.GetConfig(configFilePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return logger, c, nil
+}
+
+// exitStatus is the common end of Execute of subcommands. it logs err if not nil.
+func exitStatus(logger *slog.Logger, err error) subcommands.ExitStatus {
+	if err != nil {
+		logger.Error(err.Error(), err)
+		return subcommands.ExitFailure
+	}
+	return subcommands.ExitSuccess
+}
+
+// formatUsage returns the usage of '-f' args listing formats registered in the output package.
+func formatUsage(defaultFormat string) string {
+	return fmt.Sprintf("output data format [%s] (default is %s)", strings.Join(output.Formats(), ", "), defaultFormat)
+}
+
+// writeReport writes the report in the format registered in the output package.
+func writeReport(format, outFilePath string, report *output.Report) error {
+	writer, ok := output.Lookup(format)
+	if !ok {
+		return fmt.Errorf("output format %s is not registered", format)
+	}
+	return writer.Write(outFilePath, report)
+}
+
+// writeGlobalViews gets global views and writes their report in the format.
+// if the format is streamed, reports of views of each account and region are written as soon as
+// they are collected, by setting collected called back by getGlobalViews.
+func writeGlobalViews[V any](format, outFilePath string, collected *func(views []V), getGlobalViews func() []V, report func(views []V) *output.Report) error {
+	writer, ok := output.Lookup(format)
+	if !ok {
+		return fmt.Errorf("output format %s is not registered", format)
+	}
+	streamWriter, ok := writer.(output.StreamWriter)
+	if !ok {
+		return writer.Write(outFilePath, report(getGlobalViews()))
+	}
+
+	stream, err := streamWriter.OpenStream(outFilePath)
+	if err != nil {
+		return err
+	}
+	*collected = streamPart(stream, report)
+	globalViews := getGlobalViews()
+	// main tables aggregating all views such as summaries can't be streamed
+	if r := report(globalViews); r.Aggregated {
+		stream.WritePart(r)
+	}
+	return stream.Close()
+}
+
+// streamPart returns a callback writing the report of views of an account and region to the stream.
+// aggregated reports are skipped, since they are written once all views are collected.
+func streamPart[V any](stream output.Stream, report func(views []V) *output.Report) func(views []V) {
+	return func(views []V) {
+		if r := report(views); !r.Aggregated {
+			stream.WritePart(r)
+		}
+	}
+}
+
+// parseList parses a comma separated flag value such as "ENV,Owner,CostCenter".
+func parseList(value string) []string {
+	list := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// listFlag is a flag.Value of a comma separated list.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = parseList(value)
+	return nil
+}
+
+// listMatchedStackSets returns active stacksets matching StackNameRegex and StackTags of the filters.
+func listMatchedStackSets(cfn cloudformationiface.CloudFormationAPI, filters config.Filters) ([]*cloudformation.StackSetSummary, error) {
+	var stackSets []*cloudformation.StackSetSummary
+	input := &cloudformation.ListStackSetsInput{
+		Status: aws.String(cloudformation.StackSetStatusActive),
+	}
+	for {
+		listStackSetsOutput, err := cfn.ListStackSets(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, summary := range listStackSetsOutput.Summaries {
+			matched, _ := regexp.MatchString(filters.StackNameRegex, *summary.StackSetName)
+			if !matched {
+				continue
+			}
+			if len(filters.StackTags) != 0 {
+				describeStackSetOutput, err := cfn.DescribeStackSet(&cloudformation.DescribeStackSetInput{
+					StackSetName: summary.StackSetName,
+				})
+				if err != nil {
+					return nil, err
+				}
+				if !hasAllTags(describeStackSetOutput.StackSet.Tags, filters.StackTags) {
+					continue
+				}
+			}
+			stackSets = append(stackSets, summary)
+		}
+		if listStackSetsOutput.NextToken == nil {
+			break
+		}
+		input.NextToken = listStackSetsOutput.NextToken
+	}
+	return stackSets, nil
+}
+
+// matchesAnyGlob returns true if value matches any of the glob patterns such as "*_FAILED" or "AWS::Lambda::*".
+// an empty list of patterns matches any value.
+func matchesAnyGlob(value string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
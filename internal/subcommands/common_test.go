@@ -0,0 +1,188 @@
+package subcommands
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/gookit/config/v2"
+	cfnConfig "github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+	"github.com/stretchr/testify/assert"
+)
+
+// INVALID_CONFIG_YAML is a config with a profile and a region which don't exist, so every account and region fails.
+const INVALID_CONFIG_YAML = `
+RootConfig:
+  Credential:
+    Type: "CLI"
+    ProfileName: not-exist-profile
+  Filters:
+    Regions:
+      - "not-exist-region"
+AccountConfigs:
+  - Name: main-account
+    Id: 123456789012
+`
+
+// getInvalidConfig writes INVALID_CONFIG_YAML followed by extra to TMP_CONFIG_PATH and returns the config.
+// the file and the loaded config are cleared when the test finishes.
+func getInvalidConfig(t *testing.T, extra string) *cfnConfig.CfnGlobalViewsConfig {
+	t.Cleanup(func() {
+		config.ClearAll()
+		os.Remove(TMP_CONFIG_PATH)
+	})
+	writeTmpYaml(INVALID_CONFIG_YAML + extra)
+
+	c, err := cfnConfig.GetConfig(TMP_CONFIG_PATH)
+	assert.Nil(t, err)
+	return c
+}
+
+func TestCommon_parseSince(t *testing.T) {
+	since, err := parseSince("")
+	assert.Nil(t, err)
+	assert.True(t, since.IsZero())
+
+	since, err = parseSince("2h")
+	assert.Nil(t, err)
+	assert.WithinDuration(t, time.Now().Add(-2*time.Hour), since, time.Minute)
+
+	since, err = parseSince("7d")
+	assert.Nil(t, err)
+	assert.WithinDuration(t, time.Now().Add(-7*24*time.Hour), since, time.Minute)
+
+	_, err = parseSince("two hours")
+	assert.NotNil(t, err)
+}
+
+func TestCommon_hasAllTags(t *testing.T) {
+	stackTags := []*cloudformation.Tag{
+		{Key: aws.String("ENV"), Value: aws.String("test")},
+		{Key: aws.String("APP"), Value: aws.String("cfn-global-views")},
+	}
+
+	assert.True(t, hasAllTags(stackTags, nil))
+	assert.True(t, hasAllTags(stackTags, []cfnConfig.Tag{{Key: "ENV", Value: "test"}}))
+	assert.True(t, hasAllTags(stackTags, []cfnConfig.Tag{{Key: "ENV", Value: "test"}, {Key: "APP", Value: "cfn-global-views"}}))
+	assert.False(t, hasAllTags(stackTags, []cfnConfig.Tag{{Key: "ENV", Value: "prod"}}))
+	assert.False(t, hasAllTags(stackTags, []cfnConfig.Tag{{Key: "ENV", Value: "test"}, {Key: "OWNER", Value: "me"}}))
+}
+
+func TestCommon_sortViews(t *testing.T) {
+	views := []*CfnStacksView{
+		{AccountId: "2", Region: "r1", StackName: "a"},
+		{AccountId: "1", Region: "r2", StackName: "a"},
+		{AccountId: "1", Region: "r1", StackName: "b"},
+		{AccountId: "1", Region: "r1", StackName: "a", AccountName: "first"},
+		{AccountId: "1", Region: "r1", StackName: "a", AccountName: "second"},
+	}
+	sortViews(views, func(view *CfnStacksView) []string {
+		return []string{view.AccountId, view.Region, view.StackName}
+	})
+
+	keys := []string{}
+	for _, view := range views {
+		keys = append(keys, view.AccountId+"/"+view.Region+"/"+view.StackName+view.AccountName)
+	}
+	// views of the same keys keep their order
+	assert.Equal(t, []string{"1/r1/afirst", "1/r1/asecond", "1/r1/b", "1/r2/a", "2/r1/a"}, keys)
+}
+
+func TestCommon_matchesAnyGlob(t *testing.T) {
+	assert.True(t, matchesAnyGlob("UPDATE_FAILED", nil))
+	assert.True(t, matchesAnyGlob("UPDATE_FAILED", []string{"*_FAILED"}))
+	assert.True(t, matchesAnyGlob("AWS::Lambda::Function", []string{"AWS::S3::*", "AWS::Lambda::*"}))
+	assert.False(t, matchesAnyGlob("UPDATE_COMPLETE", []string{"*_FAILED"}))
+}
+
+func TestCommon_table_highlights(t *testing.T) {
+	type row struct {
+		StackName string
+		Value     string
+	}
+	table := newTable([]row{{StackName: "a", Value: "x"}, {StackName: "b", Value: "y"}})
+	table.highlight(1, 1)
+	table.insertColumns("StackName", []string{"Tag:ENV"}, [][]string{{"dev"}, {"prd"}})
+	assert.Equal(t, map[[2]int]bool{{1, 2}: true}, table.highlights)
+}
+
+func TestCommon_table_links(t *testing.T) {
+	type row struct {
+		StackName  string
+		ConsoleUrl string
+	}
+	table := newTable([]row{{StackName: "a", ConsoleUrl: "https://example.com/a"}, {StackName: "b"}})
+	table.link("ConsoleUrl")
+	assert.Equal(t, map[string]bool{"ConsoleUrl": true}, table.links)
+}
+
+func TestCommon_table_toOutput(t *testing.T) {
+	type row struct {
+		StackName  string
+		ConsoleUrl string
+	}
+	rows := []row{{StackName: "a", ConsoleUrl: "https://example.com/a"}}
+	table := newTable(rows)
+	table.highlight(0, 0)
+	table.link("ConsoleUrl")
+
+	outputTable := table.toOutput("stacks", rows)
+	assert.Equal(t, "stacks", outputTable.Name)
+	assert.Equal(t, []string{"StackName", "ConsoleUrl"}, outputTable.Header)
+	assert.Equal(t, [][]interface{}{{"a", "https://example.com/a"}}, outputTable.Records)
+	assert.Equal(t, map[[2]int]bool{{0, 0}: true}, outputTable.Highlights)
+	assert.Equal(t, map[string]bool{"ConsoleUrl": true}, outputTable.Links)
+	assert.Equal(t, rows, outputTable.Data)
+}
+
+func TestCommon_validateFormat(t *testing.T) {
+	assert.Nil(t, validateFormat("config.yaml", "csv", "", output.Formats()))
+	assert.NotNil(t, validateFormat("", "csv", "", output.Formats()))
+	assert.NotNil(t, validateFormat("config.yaml", "xml", "", output.Formats()))
+	assert.NotNil(t, validateFormat("config.yaml", "excel", "", output.Formats()))
+	assert.Nil(t, validateFormat("config.yaml", "excel", "out.xlsx", output.Formats()))
+}
+
+func TestCommon_writeGlobalViews_ndjson(t *testing.T) {
+	tmpNdjsonPath := "tmp.ndjson"
+	defer func() { os.Remove(tmpNdjsonPath) }()
+
+	type row struct {
+		AccountId string
+		Region    string
+		Error     string
+	}
+	c := &cfnConfig.CfnGlobalViewsConfig{AccountConfigs: []cfnConfig.AccountConfig{
+		{Id: "111111111111", Filters: cfnConfig.Filters{Regions: []string{"ap-northeast-1", "us-east-1"}}},
+	}}
+	var collected func(views []*row)
+	getGlobalViews := func() []*row {
+		return collectGlobalViews(c, true, func(accountConfig cfnConfig.AccountConfig, region string) []*row {
+			view := &row{AccountId: accountConfig.Id, Region: region}
+			if region == "us-east-1" {
+				view.Error = "denied"
+			}
+			return []*row{view}
+		}, collected)
+	}
+	report := func(views []*row) *output.Report {
+		rows := []row{}
+		for _, view := range views {
+			rows = append(rows, *view)
+		}
+		return &output.Report{Tables: []*output.Table{newTable(rows).toOutput("rows", views)}}
+	}
+
+	err := writeGlobalViews("ndjson", tmpNdjsonPath, &collected, getGlobalViews, report)
+	assert.Nil(t, err)
+	content, _ := os.ReadFile(tmpNdjsonPath)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines, `{"RecordType":"row","Table":"rows","AccountId":"111111111111","Region":"ap-northeast-1","Error":""}`)
+	assert.Contains(t, lines, `{"RecordType":"row","Table":"rows","AccountId":"111111111111","Region":"us-east-1","Error":"denied"}`)
+	assert.Contains(t, lines[2], `"RecordType":"summary","Rows":2`)
+}
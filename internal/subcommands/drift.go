@@ -0,0 +1,321 @@
+package subcommands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/google/subcommands"
+	"golang.org/x/exp/slog"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+const (
+	DRIFT_POLL_INTERVAL = 5 * time.Second
+)
+
+type CfnPropertyDifference struct {
+	PropertyPath   string
+	ExpectedValue  string
+	ActualValue    string
+	DifferenceType string
+}
+
+type CfnDriftedResource struct {
+	LogicalId           string
+	PhysicalId          string
+	Type                string
+	DriftStatus         string
+	Timestamp           string
+	PropertyDifferences []CfnPropertyDifference
+}
+
+type CfnDriftView struct {
+	AccountId        string
+	AccountName      string
+	Region           string
+	StackName        string
+	StackDriftStatus string
+	LastCheckTime    string
+	// Reused is true if drift detection results newer than max age were reused instead of detecting again
+	Reused    bool
+	Resources []CfnDriftedResource
+	Error     error
+}
+
+type CfnDriftCsvView struct {
+	AccountId              string
+	AccountName            string
+	Region                 string
+	StackName              string
+	StackDriftStatus       string
+	StackLastCheckTime     string
+	StackDriftResultReused string
+	ResourceLogicalId      string
+	ResourcePhysicalId     string
+	ResourceType           string
+	ResourceDriftStatus    string
+	PropertyPath           string
+	PropertyExpectedValue  string
+	PropertyActualValue    string
+	PropertyDifferenceType string
+	Error                  string
+}
+
+type DriftCmd struct {
+	subcommands.Command
+	configFilePath string
+	outFilePath    string
+	format         string
+	verbose        bool
+	concurrency    int
+	maxAge         string
+	timeout        time.Duration
+	maxAgeTime     time.Time
+	logger         *slog.Logger
+	config         *config.CfnGlobalViewsConfig
+	collected      func(views []*CfnDriftView)
+}
+
+func (*DriftCmd) Name() string {
+	return "drift"
+}
+func (*DriftCmd) Synopsis() string {
+	return "detect cfn stack drifts and list drifted resource properties"
+}
+func (*DriftCmd) Usage() string {
+	return "drift -c path/to/config.yaml [-concurrency 5] [-max-age 24h]"
+}
+func (c *DriftCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
+	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
+	f.StringVar(&c.format, "f", "csv", formatUsage("csv"))
+	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+	f.IntVar(&c.concurrency, "concurrency", 5, "max number of stacks detecting drifts at the same time per account and region")
+	f.StringVar(&c.maxAge, "max-age", "", "reuse drift results newer than this age e.g. 30m, 2h, 7d instead of detecting drifts again (default is always detecting)")
+	f.DurationVar(&c.timeout, "timeout", 10*time.Minute, "max time to wait for drift detection of each stack")
+}
+
+func (c *DriftCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, c.validate)
+	if err != nil {
+		fmt.Println(err.Error())
+		return subcommands.ExitFailure
+	}
+
+	return exitStatus(c.logger, writeGlobalViews(c.format, c.outFilePath, &c.collected, c.GetGlobalViews, c.Report))
+}
+
+// validate checks '-concurrency' and '-max-age' args.
+func (c *DriftCmd) validate() error {
+	if c.concurrency < 1 {
+		return fmt.Errorf("arg '-concurrency' must be at least 1")
+	}
+	var err error
+	c.maxAgeTime, err = parseSince(c.maxAge)
+	return err
+}
+
+func (c *DriftCmd) toCsvViews(views []*CfnDriftView) []CfnDriftCsvView {
+	csvViews := []CfnDriftCsvView{}
+
+	for _, view := range views {
+		base := CfnDriftCsvView{
+			AccountId:          view.AccountId,
+			AccountName:        view.AccountName,
+			Region:             view.Region,
+			StackName:          view.StackName,
+			StackDriftStatus:   view.StackDriftStatus,
+			StackLastCheckTime: view.LastCheckTime,
+			Error:              errorString(view.Error),
+		}
+		if view.StackName != "" {
+			base.StackDriftResultReused = strconv.FormatBool(view.Reused)
+		}
+		if len(view.Resources) == 0 {
+			csvViews = append(csvViews, base)
+		}
+		for _, resource := range view.Resources {
+			row := base
+			row.ResourceLogicalId = resource.LogicalId
+			row.ResourcePhysicalId = resource.PhysicalId
+			row.ResourceType = resource.Type
+			row.ResourceDriftStatus = resource.DriftStatus
+			if len(resource.PropertyDifferences) == 0 {
+				csvViews = append(csvViews, row)
+			}
+			for _, difference := range resource.PropertyDifferences {
+				row.PropertyPath = difference.PropertyPath
+				row.PropertyExpectedValue = difference.ExpectedValue
+				row.PropertyActualValue = difference.ActualValue
+				row.PropertyDifferenceType = difference.DifferenceType
+				csvViews = append(csvViews, row)
+			}
+		}
+	}
+
+	return csvViews
+}
+
+func (c *DriftCmd) Report(views []*CfnDriftView) *output.Report {
+	return &output.Report{Tables: []*output.Table{newTable(c.toCsvViews(views)).toOutput(c.Name(), views)}}
+}
+
+func (c *DriftCmd) GetGlobalViews() []*CfnDriftView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, c.collected)
+
+	sortViews(globalViews, func(view *CfnDriftView) []string {
+		return []string{view.AccountId, view.Region, view.StackName}
+	})
+
+	return globalViews
+}
+
+func (c *DriftCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnDriftView {
+	c.logger.Info("get cfn drifts", "accountId", accountConfig.Id, "region", region)
+
+	cfn := cloudformation.New(newSession(accountConfig, region))
+
+	matchedStacks, err := describeMatchedStacks(cfn, accountConfig.Filters)
+	if err != nil {
+		return []*CfnDriftView{{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Error:       err,
+		}}
+	}
+
+	views := make([]*CfnDriftView, len(matchedStacks))
+	semaphore := make(chan struct{}, c.concurrency)
+	wg := sync.WaitGroup{}
+	for i, matchedStack := range matchedStacks {
+		wg.Add(1)
+		go func(i int, matchedStack *cloudformation.Stack) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			view := c.getStackDrift(cfn, matchedStack)
+			view.AccountId = accountConfig.Id
+			view.AccountName = accountConfig.Name
+			view.Region = region
+			views[i] = view
+		}(i, matchedStack)
+	}
+	wg.Wait()
+
+	return views
+}
+
+// getStackDrift detects drifts of the stack unless its last drift check is newer than max age,
+// and returns drifted resources.
+func (c *DriftCmd) getStackDrift(cfn cloudformationiface.CloudFormationAPI, stack *cloudformation.Stack) *CfnDriftView {
+	view := &CfnDriftView{
+		StackName: *stack.StackName,
+	}
+
+	var lastCheckTime *time.Time
+	if d := stack.DriftInformation; d != nil {
+		view.StackDriftStatus = aws.StringValue(d.StackDriftStatus)
+		lastCheckTime = d.LastCheckTimestamp
+	}
+
+	if !c.maxAgeTime.IsZero() && lastCheckTime != nil && lastCheckTime.After(c.maxAgeTime) {
+		view.Reused = true
+	} else {
+		c.logger.Info(fmt.Sprintf("detect drifts of cfn stack: %s", *stack.StackName))
+		detectOutput, err := cfn.DetectStackDrift(&cloudformation.DetectStackDriftInput{
+			StackName: stack.StackName,
+		})
+		if err != nil {
+			view.Error = err
+			return view
+		}
+		statusOutput, err := c.waitDriftDetection(cfn, *detectOutput.StackDriftDetectionId)
+		if err != nil {
+			view.Error = err
+			return view
+		}
+		view.StackDriftStatus = aws.StringValue(statusOutput.StackDriftStatus)
+		lastCheckTime = statusOutput.Timestamp
+	}
+	view.LastCheckTime = formatTime(lastCheckTime)
+
+	input := &cloudformation.DescribeStackResourceDriftsInput{
+		StackName: stack.StackName,
+		StackResourceDriftStatusFilters: aws.StringSlice([]string{
+			cloudformation.StackResourceDriftStatusModified,
+			cloudformation.StackResourceDriftStatusDeleted,
+		}),
+	}
+	for {
+		driftsOutput, err := cfn.DescribeStackResourceDrifts(input)
+		if err != nil {
+			view.Error = err
+			return view
+		}
+		for _, drift := range driftsOutput.StackResourceDrifts {
+			resource := CfnDriftedResource{
+				LogicalId:   aws.StringValue(drift.LogicalResourceId),
+				PhysicalId:  aws.StringValue(drift.PhysicalResourceId),
+				Type:        aws.StringValue(drift.ResourceType),
+				DriftStatus: aws.StringValue(drift.StackResourceDriftStatus),
+				Timestamp:   formatTime(drift.Timestamp),
+			}
+			for _, difference := range drift.PropertyDifferences {
+				resource.PropertyDifferences = append(resource.PropertyDifferences, CfnPropertyDifference{
+					PropertyPath:   aws.StringValue(difference.PropertyPath),
+					ExpectedValue:  aws.StringValue(difference.ExpectedValue),
+					ActualValue:    aws.StringValue(difference.ActualValue),
+					DifferenceType: aws.StringValue(difference.DifferenceType),
+				})
+			}
+			view.Resources = append(view.Resources, resource)
+		}
+		if driftsOutput.NextToken == nil {
+			break
+		}
+		input.NextToken = driftsOutput.NextToken
+	}
+
+	return view
+}
+
+// waitDriftDetection polls the drift detection status until it completes or times out.
+func (c *DriftCmd) waitDriftDetection(cfn cloudformationiface.CloudFormationAPI, detectionId string) (*cloudformation.DescribeStackDriftDetectionStatusOutput, error) {
+	deadline := time.Now().Add(c.timeout)
+	for {
+		statusOutput, err := cfn.DescribeStackDriftDetectionStatus(&cloudformation.DescribeStackDriftDetectionStatusInput{
+			StackDriftDetectionId: aws.String(detectionId),
+		})
+		if err != nil {
+			return nil, err
+		}
+		switch aws.StringValue(statusOutput.DetectionStatus) {
+		case cloudformation.StackDriftDetectionStatusDetectionComplete:
+			return statusOutput, nil
+		case cloudformation.StackDriftDetectionStatusDetectionFailed:
+			// detection fails if some resources don't support drift detection,
+			// but results of the other resources are still available
+			if statusOutput.StackDriftStatus != nil {
+				return statusOutput, nil
+			}
+			return nil, fmt.Errorf("drift detection failed: %s", aws.StringValue(statusOutput.DetectionStatusReason))
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("drift detection %s timed out after %s", detectionId, c.timeout)
+		}
+		time.Sleep(DRIFT_POLL_INTERVAL)
+	}
+}
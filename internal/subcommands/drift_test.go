@@ -0,0 +1,116 @@
+package subcommands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDriftClient struct {
+	cloudformationiface.CloudFormationAPI
+	detected bool
+}
+
+func (m *mockDriftClient) DetectStackDrift(input *cloudformation.DetectStackDriftInput) (*cloudformation.DetectStackDriftOutput, error) {
+	m.detected = true
+	return &cloudformation.DetectStackDriftOutput{StackDriftDetectionId: aws.String("detection-id")}, nil
+}
+
+func (m *mockDriftClient) DescribeStackDriftDetectionStatus(input *cloudformation.DescribeStackDriftDetectionStatusInput) (*cloudformation.DescribeStackDriftDetectionStatusOutput, error) {
+	return &cloudformation.DescribeStackDriftDetectionStatusOutput{
+		DetectionStatus:  aws.String(cloudformation.StackDriftDetectionStatusDetectionComplete),
+		StackDriftStatus: aws.String(cloudformation.StackDriftStatusDrifted),
+		Timestamp:        aws.Time(time.Now()),
+	}, nil
+}
+
+func (m *mockDriftClient) DescribeStackResourceDrifts(input *cloudformation.DescribeStackResourceDriftsInput) (*cloudformation.DescribeStackResourceDriftsOutput, error) {
+	return &cloudformation.DescribeStackResourceDriftsOutput{
+		StackResourceDrifts: []*cloudformation.StackResourceDrift{
+			{
+				LogicalResourceId:        aws.String("Bucket"),
+				PhysicalResourceId:       aws.String("bucket-name"),
+				ResourceType:             aws.String("AWS::S3::Bucket"),
+				StackResourceDriftStatus: aws.String(cloudformation.StackResourceDriftStatusModified),
+				PropertyDifferences: []*cloudformation.PropertyDifference{
+					{
+						PropertyPath:   aws.String("/VersioningConfiguration/Status"),
+						ExpectedValue:  aws.String("Enabled"),
+						ActualValue:    aws.String("Suspended"),
+						DifferenceType: aws.String(cloudformation.DifferenceTypeNotEqual),
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func TestDrift_invalid(t *testing.T) {
+	c := getInvalidConfig(t, "")
+
+	cmd := DriftCmd{
+		config:      c,
+		logger:      TEST_LOGGER,
+		concurrency: 1,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 1, len(views))
+	assert.NotNil(t, views[0].Error, views[0])
+
+}
+
+func TestDrift_detect(t *testing.T) {
+	cfn := &mockDriftClient{}
+	cmd := DriftCmd{logger: TEST_LOGGER, timeout: time.Minute}
+
+	view := cmd.getStackDrift(cfn, &cloudformation.Stack{StackName: aws.String("stack")})
+	assert.Nil(t, view.Error)
+	assert.True(t, cfn.detected)
+	assert.False(t, view.Reused)
+	assert.Equal(t, cloudformation.StackDriftStatusDrifted, view.StackDriftStatus)
+	assert.Equal(t, 1, len(view.Resources))
+	assert.Equal(t, "Suspended", view.Resources[0].PropertyDifferences[0].ActualValue)
+
+	csvViews := cmd.toCsvViews([]*CfnDriftView{view})
+	assert.Equal(t, 1, len(csvViews))
+	assert.Equal(t, "/VersioningConfiguration/Status", csvViews[0].PropertyPath)
+	assert.Equal(t, "false", csvViews[0].StackDriftResultReused)
+}
+
+func TestDrift_reuse(t *testing.T) {
+	maxAgeTime, err := parseSince("1d")
+	assert.Nil(t, err)
+	cmd := DriftCmd{logger: TEST_LOGGER, maxAgeTime: maxAgeTime}
+
+	// a recent result is reused
+	cfn := &mockDriftClient{}
+	view := cmd.getStackDrift(cfn, &cloudformation.Stack{
+		StackName: aws.String("stack"),
+		DriftInformation: &cloudformation.StackDriftInformation{
+			StackDriftStatus:   aws.String(cloudformation.StackDriftStatusDrifted),
+			LastCheckTimestamp: aws.Time(time.Now().Add(-time.Hour)),
+		},
+	})
+	assert.Nil(t, view.Error)
+	assert.False(t, cfn.detected)
+	assert.True(t, view.Reused)
+	assert.Equal(t, 1, len(view.Resources))
+
+	// an old result is detected again
+	cfn = &mockDriftClient{}
+	view = cmd.getStackDrift(cfn, &cloudformation.Stack{
+		StackName: aws.String("stack"),
+		DriftInformation: &cloudformation.StackDriftInformation{
+			StackDriftStatus:   aws.String(cloudformation.StackDriftStatusInSync),
+			LastCheckTimestamp: aws.Time(time.Now().Add(-48 * time.Hour)),
+		},
+	})
+	assert.Nil(t, view.Error)
+	assert.True(t, cfn.detected)
+	assert.False(t, view.Reused)
+}
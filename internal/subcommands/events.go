@@ -0,0 +1,247 @@
+package subcommands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/google/subcommands"
+	"golang.org/x/exp/slog"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+type CfnEvent struct {
+	EventId      string
+	Timestamp    string
+	LogicalId    string
+	PhysicalId   string
+	Type         string
+	Status       string
+	StatusReason string
+}
+
+type CfnEventsView struct {
+	AccountId   string
+	AccountName string
+	Region      string
+	StackName   string
+	Events      []CfnEvent
+	Error       error
+}
+
+type CfnEventsCsvView struct {
+	AccountId           string
+	AccountName         string
+	Region              string
+	StackName           string
+	EventTimestamp      string
+	EventLogicalId      string
+	EventPhysicalId     string
+	EventResourceType   string
+	EventResourceStatus string
+	EventStatusReason   string
+	EventId             string
+	Error               string
+}
+
+type EventsCmd struct {
+	subcommands.Command
+	configFilePath string
+	outFilePath    string
+	format         string
+	verbose        bool
+	since          string
+	statuses       []string
+	logicalIds     []string
+	sinceTime      time.Time
+	logger         *slog.Logger
+	config         *config.CfnGlobalViewsConfig
+	collected      func(views []*CfnEventsView)
+}
+
+func (*EventsCmd) Name() string {
+	return "events"
+}
+func (*EventsCmd) Synopsis() string {
+	return "list cfn stack events of all stacks in chronological order"
+}
+func (*EventsCmd) Usage() string {
+	return "events -c path/to/config.yaml [-since 2h] [-status *_FAILED] [-logical-id MyBucket*]"
+}
+func (c *EventsCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
+	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
+	f.StringVar(&c.format, "f", "csv", formatUsage("csv"))
+	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+	f.StringVar(&c.since, "since", "24h", "only list events within this time window e.g. 30m, 2h, 7d. set empty string to list all events")
+	f.Var((*listFlag)(&c.statuses), "status", "comma separated glob patterns of resource statuses e.g. *_FAILED,*_ROLLBACK_*")
+	f.Var((*listFlag)(&c.logicalIds), "logical-id", "comma separated glob patterns of logical ids e.g. MyBucket,MyFunction*")
+}
+
+func (c *EventsCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, c.validate)
+	if err != nil {
+		fmt.Println(err.Error())
+		return subcommands.ExitFailure
+	}
+
+	return exitStatus(c.logger, writeGlobalViews(c.format, c.outFilePath, &c.collected, c.GetGlobalViews, c.Report))
+}
+
+// validate checks '-since' arg.
+func (c *EventsCmd) validate() error {
+	var err error
+	c.sinceTime, err = parseSince(c.since)
+	return err
+}
+
+// toCsvViews flattens events of all stacks into one chronological list.
+// views without events, such as errors, are listed first.
+func (c *EventsCmd) toCsvViews(views []*CfnEventsView) []CfnEventsCsvView {
+	csvViews := []CfnEventsCsvView{}
+
+	for _, view := range views {
+		if len(view.Events) == 0 {
+			csvViews = append(csvViews, CfnEventsCsvView{
+				AccountId:   view.AccountId,
+				AccountName: view.AccountName,
+				Region:      view.Region,
+				StackName:   view.StackName,
+				Error:       errorString(view.Error),
+			})
+		}
+		for _, event := range view.Events {
+			csvViews = append(csvViews, CfnEventsCsvView{
+				AccountId:           view.AccountId,
+				AccountName:         view.AccountName,
+				Region:              view.Region,
+				StackName:           view.StackName,
+				EventTimestamp:      event.Timestamp,
+				EventLogicalId:      event.LogicalId,
+				EventPhysicalId:     event.PhysicalId,
+				EventResourceType:   event.Type,
+				EventResourceStatus: event.Status,
+				EventStatusReason:   event.StatusReason,
+				EventId:             event.EventId,
+				Error:               errorString(view.Error),
+			})
+		}
+	}
+
+	// timestamps are RFC3339 in UTC, so they are sorted as strings
+	sort.SliceStable(csvViews, func(i, j int) bool {
+		return csvViews[i].EventTimestamp < csvViews[j].EventTimestamp
+	})
+
+	return csvViews
+}
+
+func (c *EventsCmd) Report(views []*CfnEventsView) *output.Report {
+	csvViews := c.toCsvViews(views)
+	return &output.Report{Tables: []*output.Table{newTable(csvViews).toOutput(c.Name(), csvViews)}}
+}
+
+func (c *EventsCmd) GetGlobalViews() []*CfnEventsView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, c.collected)
+
+	sortViews(globalViews, func(view *CfnEventsView) []string {
+		return []string{view.AccountId, view.Region, view.StackName}
+	})
+
+	return globalViews
+}
+
+func (c *EventsCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnEventsView {
+	views := []*CfnEventsView{}
+	c.logger.Info("get cfn events", "accountId", accountConfig.Id, "region", region)
+
+	cfn := cloudformation.New(newSession(accountConfig, region))
+
+	matchedStacks, err := describeMatchedStacks(cfn, accountConfig.Filters)
+	if err != nil {
+		return append(views, &CfnEventsView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Error:       err,
+		})
+	}
+
+	for _, matchedStack := range matchedStacks {
+		events, err := c.describeEvents(cfn, *matchedStack.StackId)
+		if err == nil && len(events) == 0 {
+			continue
+		}
+		views = append(views, &CfnEventsView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			StackName:   *matchedStack.StackName,
+			Events:      events,
+			Error:       err,
+		})
+	}
+
+	return views
+}
+
+// describeEvents returns events of the stack within the time window and matching the status and logical id patterns.
+func (c *EventsCmd) describeEvents(cfn cloudformationiface.CloudFormationAPI, stackId string) ([]CfnEvent, error) {
+	var events []CfnEvent
+	input := &cloudformation.DescribeStackEventsInput{
+		StackName: aws.String(stackId),
+	}
+	for {
+		describeEventsOutput, err := cfn.DescribeStackEvents(input)
+		if err != nil {
+			return events, err
+		}
+		// events are returned in reverse chronological order
+		outOfWindow := false
+		for _, event := range describeEventsOutput.StackEvents {
+			if event.Timestamp != nil && event.Timestamp.Before(c.sinceTime) {
+				outOfWindow = true
+				break
+			}
+			if !matchesAnyGlob(aws.StringValue(event.ResourceStatus), c.statuses) || !matchesAnyGlob(aws.StringValue(event.LogicalResourceId), c.logicalIds) {
+				continue
+			}
+			events = append(events, newCfnEvent(event))
+		}
+		if outOfWindow || describeEventsOutput.NextToken == nil {
+			break
+		}
+		input.NextToken = describeEventsOutput.NextToken
+	}
+
+	// reverse into chronological order
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
+
+func newCfnEvent(event *cloudformation.StackEvent) CfnEvent {
+	timestamp := ""
+	if event.Timestamp != nil {
+		timestamp = event.Timestamp.UTC().Format(TIME_FORMAT)
+	}
+	return CfnEvent{
+		EventId:      aws.StringValue(event.EventId),
+		Timestamp:    timestamp,
+		LogicalId:    aws.StringValue(event.LogicalResourceId),
+		PhysicalId:   aws.StringValue(event.PhysicalResourceId),
+		Type:         aws.StringValue(event.ResourceType),
+		Status:       aws.StringValue(event.ResourceStatus),
+		StatusReason: aws.StringValue(event.ResourceStatusReason),
+	}
+}
@@ -0,0 +1,138 @@
+package subcommands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEventsClient struct {
+	cloudformationiface.CloudFormationAPI
+	pages [][]*cloudformation.StackEvent
+	calls int
+}
+
+func (m *mockEventsClient) DescribeStackEvents(input *cloudformation.DescribeStackEventsInput) (*cloudformation.DescribeStackEventsOutput, error) {
+	m.calls++
+	page := 0
+	if input.NextToken != nil {
+		page = 1
+	}
+	output := &cloudformation.DescribeStackEventsOutput{StackEvents: m.pages[page]}
+	if page+1 < len(m.pages) {
+		output.NextToken = aws.String("next")
+	}
+	return output, nil
+}
+
+func newTestEvent(logicalId, status string, ago time.Duration) *cloudformation.StackEvent {
+	return &cloudformation.StackEvent{
+		EventId:           aws.String(logicalId + status),
+		LogicalResourceId: aws.String(logicalId),
+		ResourceStatus:    aws.String(status),
+		Timestamp:         aws.Time(time.Now().Add(-ago)),
+	}
+}
+
+func TestEvents_invalid(t *testing.T) {
+	c := getInvalidConfig(t, "")
+
+	cmd := EventsCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 1, len(views))
+	assert.NotNil(t, views[0].Error, views[0])
+
+}
+
+func TestEvents_describeEvents(t *testing.T) {
+	cfn := &mockEventsClient{pages: [][]*cloudformation.StackEvent{
+		{
+			newTestEvent("Stack", "UPDATE_ROLLBACK_COMPLETE", 1*time.Minute),
+			newTestEvent("Bucket", "UPDATE_FAILED", 2*time.Minute),
+			newTestEvent("Function", "UPDATE_FAILED", 3*time.Minute),
+		},
+		{
+			newTestEvent("Bucket", "UPDATE_IN_PROGRESS", 4*time.Minute),
+			newTestEvent("Bucket", "CREATE_FAILED", 3*time.Hour),
+		},
+	}}
+
+	sinceTime, _ := parseSince("2h")
+	cmd := EventsCmd{sinceTime: sinceTime}
+	events, err := cmd.describeEvents(cfn, "stack")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(events))
+	assert.Equal(t, "UPDATE_IN_PROGRESS", events[0].Status)
+	assert.Equal(t, "UPDATE_ROLLBACK_COMPLETE", events[3].Status)
+
+	cmd = EventsCmd{statuses: []string{"*_FAILED"}, logicalIds: []string{"Bucket"}}
+	events, err = cmd.describeEvents(cfn, "stack")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, "CREATE_FAILED", events[0].Status)
+	assert.Equal(t, "UPDATE_FAILED", events[1].Status)
+}
+
+func TestEvents_chronological(t *testing.T) {
+	cmd := EventsCmd{}
+	views := []*CfnEventsView{
+		{StackName: "stack-1", Events: []CfnEvent{{Timestamp: "2023-02-01T00:00:00Z"}, {Timestamp: "2023-02-03T00:00:00Z"}}},
+		{StackName: "stack-2", Events: []CfnEvent{{Timestamp: "2023-02-02T00:00:00Z"}}},
+	}
+
+	csvViews := cmd.toCsvViews(views)
+	assert.Equal(t, 3, len(csvViews))
+	assert.Equal(t, "stack-1", csvViews[0].StackName)
+	assert.Equal(t, "stack-2", csvViews[1].StackName)
+	assert.Equal(t, "stack-1", csvViews[2].StackName)
+}
+
+func TestEvents_ordering(t *testing.T) {
+	timestamp := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	event := func(logicalId string, at time.Time) *cloudformation.StackEvent {
+		e := newTestEvent(logicalId, "UPDATE_COMPLETE", 0)
+		e.Timestamp = aws.Time(at)
+		return e
+	}
+	cfn := &mockEventsClient{pages: [][]*cloudformation.StackEvent{
+		// events of the same timestamp are still in reverse chronological order
+		{event("Third", timestamp), event("Second", timestamp), event("First", timestamp.Add(-time.Hour)), event("Old", timestamp.Add(-48*time.Hour))},
+		{event("Older", timestamp.Add(-72*time.Hour))},
+	}}
+
+	cmd := EventsCmd{sinceTime: timestamp.Add(-24 * time.Hour)}
+	events, err := cmd.describeEvents(cfn, "stack")
+	assert.Nil(t, err)
+	logicalIds := []string{}
+	for _, e := range events {
+		logicalIds = append(logicalIds, e.LogicalId)
+	}
+	assert.Equal(t, []string{"First", "Second", "Third"}, logicalIds)
+	// the first page reaches the start of the window, so the next page isn't described
+	assert.Equal(t, 1, cfn.calls)
+
+	views := []*CfnEventsView{
+		{AccountId: "1", StackName: "stack-1", Events: []CfnEvent{
+			{LogicalId: "A", Timestamp: "2023-02-01T00:00:00Z"}, {LogicalId: "B", Timestamp: "2023-02-02T00:00:00Z"},
+		}},
+		{AccountId: "2", StackName: "stack-2", Error: assert.AnError},
+		{AccountId: "2", StackName: "stack-3", Events: []CfnEvent{
+			{LogicalId: "C", Timestamp: "2023-02-01T00:00:00Z"}, {LogicalId: "D", Timestamp: "2023-01-31T00:00:00Z"},
+		}},
+	}
+	csvViews := cmd.toCsvViews(views)
+	order := []string{}
+	for _, csvView := range csvViews {
+		order = append(order, csvView.StackName+"/"+csvView.EventLogicalId)
+	}
+	// errors first, then events of all stacks interleaved by time, keeping the order of views at the same time
+	assert.Equal(t, []string{"stack-2/", "stack-3/D", "stack-1/A", "stack-3/C", "stack-1/B"}, order)
+}
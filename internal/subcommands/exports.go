@@ -0,0 +1,257 @@
+package subcommands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/google/subcommands"
+	"golang.org/x/exp/slog"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+type CfnExport struct {
+	Name            string
+	Value           string
+	ImportingStacks []string
+	ImportCount     int
+	Unused          bool
+}
+
+type CfnExportsView struct {
+	AccountId        string
+	AccountName      string
+	Region           string
+	StackName        string
+	StackId          string
+	MatchedByFilters bool
+	Exports          []CfnExport
+	Error            error
+}
+
+type CfnExportsCsvView struct {
+	AccountId             string
+	AccountName           string
+	Region                string
+	StackName             string
+	StackMatchedByFilters string
+	ExportName            string
+	ExportValue           string
+	ExportImportingStacks string
+	ExportImportCount     string
+	ExportUnused          string
+	Error                 string
+}
+
+type ExportsCmd struct {
+	subcommands.Command
+	configFilePath string
+	outFilePath    string
+	format         string
+	verbose        bool
+	logger         *slog.Logger
+	config         *config.CfnGlobalViewsConfig
+	collected      func(views []*CfnExportsView)
+}
+
+func (*ExportsCmd) Name() string {
+	return "exports"
+}
+func (*ExportsCmd) Synopsis() string {
+	return "list cfn exports and stacks importing them"
+}
+func (*ExportsCmd) Usage() string {
+	return "exports -c path/to/config.yaml"
+}
+func (c *ExportsCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
+	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
+	f.StringVar(&c.format, "f", "csv", formatUsage("csv"))
+	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+}
+
+func (c *ExportsCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return subcommands.ExitFailure
+	}
+
+	return exitStatus(c.logger, writeGlobalViews(c.format, c.outFilePath, &c.collected, c.GetGlobalViews, c.Report))
+}
+
+func (c *ExportsCmd) toCsvViews(views []*CfnExportsView) []CfnExportsCsvView {
+	csvViews := []CfnExportsCsvView{}
+
+	for _, view := range views {
+		matchedByFilters := ""
+		if view.StackName != "" {
+			matchedByFilters = strconv.FormatBool(view.MatchedByFilters)
+		}
+		if len(view.Exports) == 0 {
+			csvViews = append(csvViews, CfnExportsCsvView{
+				AccountId:             view.AccountId,
+				AccountName:           view.AccountName,
+				Region:                view.Region,
+				StackName:             view.StackName,
+				StackMatchedByFilters: matchedByFilters,
+				Error:                 errorString(view.Error),
+			})
+		}
+		for _, export := range view.Exports {
+			csvViews = append(csvViews, CfnExportsCsvView{
+				AccountId:             view.AccountId,
+				AccountName:           view.AccountName,
+				Region:                view.Region,
+				StackName:             view.StackName,
+				StackMatchedByFilters: matchedByFilters,
+				ExportName:            export.Name,
+				ExportValue:           export.Value,
+				ExportImportingStacks: strings.Join(export.ImportingStacks, ","),
+				ExportImportCount:     strconv.Itoa(export.ImportCount),
+				ExportUnused:          strconv.FormatBool(export.Unused),
+				Error:                 errorString(view.Error),
+			})
+		}
+	}
+
+	return csvViews
+}
+
+func (c *ExportsCmd) Report(views []*CfnExportsView) *output.Report {
+	return &output.Report{Tables: []*output.Table{newTable(c.toCsvViews(views)).toOutput(c.Name(), views)}}
+}
+
+func (c *ExportsCmd) GetGlobalViews() []*CfnExportsView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, c.collected)
+
+	sortViews(globalViews, func(view *CfnExportsView) []string {
+		return []string{view.AccountId, view.Region, view.StackName}
+	})
+
+	return globalViews
+}
+
+// getViews lists all exports at the account and region regardless of the filters,
+// and flags whether each exporting stack matches the filters.
+func (c *ExportsCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnExportsView {
+	views := []*CfnExportsView{}
+	c.logger.Info("get cfn exports", "accountId", accountConfig.Id, "region", region)
+
+	cfn := cloudformation.New(newSession(accountConfig, region))
+	errorView := func(err error) []*CfnExportsView {
+		return append(views, &CfnExportsView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Error:       err,
+		})
+	}
+
+	matchedStacks, err := describeMatchedStacks(cfn, accountConfig.Filters)
+	if err != nil {
+		return errorView(err)
+	}
+	matchedStackIds := map[string]bool{}
+	for _, matchedStack := range matchedStacks {
+		matchedStackIds[*matchedStack.StackId] = true
+	}
+
+	exports, err := listExports(cfn)
+	if err != nil {
+		return errorView(err)
+	}
+
+	viewsByStackId := map[string]*CfnExportsView{}
+	for _, export := range exports {
+		stackId := aws.StringValue(export.ExportingStackId)
+		view, ok := viewsByStackId[stackId]
+		if !ok {
+			view = &CfnExportsView{
+				AccountId:        accountConfig.Id,
+				AccountName:      accountConfig.Name,
+				Region:           region,
+				StackName:        stackNameFromId(stackId),
+				StackId:          stackId,
+				MatchedByFilters: matchedStackIds[stackId],
+			}
+			viewsByStackId[stackId] = view
+			views = append(views, view)
+		}
+
+		importingStacks, err := listImports(cfn, *export.Name)
+		if err != nil {
+			view.Error = err
+		}
+		view.Exports = append(view.Exports, CfnExport{
+			Name:            *export.Name,
+			Value:           aws.StringValue(export.Value),
+			ImportingStacks: importingStacks,
+			ImportCount:     len(importingStacks),
+			Unused:          err == nil && len(importingStacks) == 0,
+		})
+	}
+
+	return views
+}
+
+func listExports(cfn cloudformationiface.CloudFormationAPI) ([]*cloudformation.Export, error) {
+	var exports []*cloudformation.Export
+	input := &cloudformation.ListExportsInput{}
+	for {
+		listExportsOutput, err := cfn.ListExports(input)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, listExportsOutput.Exports...)
+		if listExportsOutput.NextToken == nil {
+			break
+		}
+		input.NextToken = listExportsOutput.NextToken
+	}
+	return exports, nil
+}
+
+// listImports returns names of stacks importing the export. an export not imported by any stack is
+// reported as a ValidationError by the api, so it is returned as an empty list.
+func listImports(cfn cloudformationiface.CloudFormationAPI, exportName string) ([]string, error) {
+	importingStacks := []string{}
+	input := &cloudformation.ListImportsInput{
+		ExportName: aws.String(exportName),
+	}
+	for {
+		listImportsOutput, err := cfn.ListImports(input)
+		if err != nil {
+			if aerr, ok := err.(awserr.Error); ok && strings.Contains(aerr.Message(), "is not imported by any stack") {
+				return importingStacks, nil
+			}
+			return importingStacks, err
+		}
+		importingStacks = append(importingStacks, aws.StringValueSlice(listImportsOutput.Imports)...)
+		if listImportsOutput.NextToken == nil {
+			break
+		}
+		input.NextToken = listImportsOutput.NextToken
+	}
+	return importingStacks, nil
+}
+
+// stackNameFromId returns the stack name part of a stack id
+// such as arn:aws:cloudformation:ap-northeast-1:123456789012:stack/StackName/xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+func stackNameFromId(stackId string) string {
+	parts := strings.Split(stackId, "/")
+	if len(parts) < 2 {
+		return stackId
+	}
+	return parts[1]
+}
@@ -0,0 +1,55 @@
+package subcommands
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockImportsClient struct {
+	cloudformationiface.CloudFormationAPI
+	imports map[string][]string
+}
+
+func (m *mockImportsClient) ListImports(input *cloudformation.ListImportsInput) (*cloudformation.ListImportsOutput, error) {
+	imports, ok := m.imports[*input.ExportName]
+	if !ok {
+		return nil, awserr.New("ValidationError", "Export '"+*input.ExportName+"' is not imported by any stack.", nil)
+	}
+	return &cloudformation.ListImportsOutput{Imports: aws.StringSlice(imports)}, nil
+}
+
+func TestExports_invalid(t *testing.T) {
+	c := getInvalidConfig(t, "")
+
+	cmd := ExportsCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 1, len(views))
+	assert.NotNil(t, views[0].Error, views[0])
+
+}
+
+func TestExports_listImports(t *testing.T) {
+	cfn := &mockImportsClient{imports: map[string][]string{"used": {"stack-1", "stack-2"}}}
+
+	importingStacks, err := listImports(cfn, "used")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"stack-1", "stack-2"}, importingStacks)
+
+	importingStacks, err = listImports(cfn, "unused")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(importingStacks))
+}
+
+func TestExports_stackNameFromId(t *testing.T) {
+	assert.Equal(t, "CfnGlobalViewsMainStack", stackNameFromId("arn:aws:cloudformation:ap-northeast-1:123456789012:stack/CfnGlobalViewsMainStack/2f7c5a10-b1a3-11ed-9d3a-0a1b2c3d4e5f"))
+	assert.Equal(t, "not-an-arn", stackNameFromId("not-an-arn"))
+}
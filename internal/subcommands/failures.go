@@ -0,0 +1,358 @@
+package subcommands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/google/subcommands"
+	"golang.org/x/exp/slog"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+const (
+	// max depth to follow failures into nested stacks
+	MAX_NESTED_STACK_DEPTH = 5
+)
+
+var (
+	FAILED_STACK_STATUS_PATTERNS = []string{"*_FAILED", "*ROLLBACK_*"}
+
+	// statuses which start a stack operation by a user
+	OPERATION_START_STATUSES = []string{
+		cloudformation.ResourceStatusCreateInProgress,
+		cloudformation.ResourceStatusUpdateInProgress,
+		cloudformation.ResourceStatusDeleteInProgress,
+		cloudformation.ResourceStatusImportInProgress,
+	}
+
+	// patterns replaced to group similar failure reasons, applied in order
+	reasonNormalizers = []struct {
+		pattern     *regexp.Regexp
+		replacement string
+	}{
+		{regexp.MustCompile(`(?i)request id: [0-9a-z-]+`), "Request ID: <id>"},
+		{regexp.MustCompile(`arn:aws[a-z-]*:[^\s"',\]\)]+`), "<arn>"},
+		{regexp.MustCompile(`(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`), "<id>"},
+		{regexp.MustCompile(`\b(sg|subnet|vpc|i|ami|vol|eni|rtb|igw|nat|acl)-[0-9a-f]+\b`), "<id>"},
+		{regexp.MustCompile(`[0-9]+`), "<n>"},
+	}
+)
+
+type CfnFailure struct {
+	OperationStartTime string
+	LogicalId          string
+	PhysicalId         string
+	Type               string
+	Status             string
+	StatusReason       string
+	Timestamp          string
+	// NestedStackName is the name of the nested stack the failure came from, if any
+	NestedStackName string
+	ReasonGroup     string
+}
+
+type CfnFailuresView struct {
+	AccountId   string
+	AccountName string
+	Region      string
+	StackName   string
+	StackStatus string
+	Failure     CfnFailure
+	Error       error
+}
+
+type CfnFailuresCsvView struct {
+	AccountId          string
+	AccountName        string
+	Region             string
+	StackName          string
+	StackStatus        string
+	OperationStartTime string
+	FailedLogicalId    string
+	FailedPhysicalId   string
+	FailedResourceType string
+	FailedStatus       string
+	FailedStatusReason string
+	FailedTimestamp    string
+	FailedNestedStack  string
+	FailureReasonGroup string
+	Error              string
+}
+
+type CfnFailureGroup struct {
+	ReasonGroup string
+	Count       int
+	Stacks      []string
+}
+
+type CfnFailureGroupsCsvView struct {
+	ReasonGroup string
+	Count       string
+	Stacks      string
+}
+
+type FailuresCmd struct {
+	subcommands.Command
+	configFilePath string
+	outFilePath    string
+	format         string
+	verbose        bool
+	group          bool
+	logger         *slog.Logger
+	config         *config.CfnGlobalViewsConfig
+	collected      func(views []*CfnFailuresView)
+}
+
+func (*FailuresCmd) Name() string {
+	return "failures"
+}
+func (*FailuresCmd) Synopsis() string {
+	return "list root causes of failed and rolled back cfn stacks"
+}
+func (*FailuresCmd) Usage() string {
+	return "failures -c path/to/config.yaml [-group]"
+}
+func (c *FailuresCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
+	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
+	f.StringVar(&c.format, "f", "csv", formatUsage("csv"))
+	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+	f.BoolVar(&c.group, "group", false, "if set, output failures grouped by similar reasons instead of a row per stack. excel output always has both sheets")
+}
+
+func (c *FailuresCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return subcommands.ExitFailure
+	}
+
+	return exitStatus(c.logger, writeGlobalViews(c.format, c.outFilePath, &c.collected, c.GetGlobalViews, c.Report))
+}
+
+func (c *FailuresCmd) toCsvViews(views []*CfnFailuresView) []CfnFailuresCsvView {
+	csvViews := []CfnFailuresCsvView{}
+
+	for _, view := range views {
+		csvViews = append(csvViews, CfnFailuresCsvView{
+			AccountId:          view.AccountId,
+			AccountName:        view.AccountName,
+			Region:             view.Region,
+			StackName:          view.StackName,
+			StackStatus:        view.StackStatus,
+			OperationStartTime: view.Failure.OperationStartTime,
+			FailedLogicalId:    view.Failure.LogicalId,
+			FailedPhysicalId:   view.Failure.PhysicalId,
+			FailedResourceType: view.Failure.Type,
+			FailedStatus:       view.Failure.Status,
+			FailedStatusReason: view.Failure.StatusReason,
+			FailedTimestamp:    view.Failure.Timestamp,
+			FailedNestedStack:  view.Failure.NestedStackName,
+			FailureReasonGroup: view.Failure.ReasonGroup,
+			Error:              errorString(view.Error),
+		})
+	}
+
+	return csvViews
+}
+
+func (c *FailuresCmd) toGroupCsvViews(groups []CfnFailureGroup) []CfnFailureGroupsCsvView {
+	csvViews := []CfnFailureGroupsCsvView{}
+	for _, group := range groups {
+		csvViews = append(csvViews, CfnFailureGroupsCsvView{
+			ReasonGroup: group.ReasonGroup,
+			Count:       strconv.Itoa(group.Count),
+			Stacks:      strings.Join(group.Stacks, ","),
+		})
+	}
+	return csvViews
+}
+
+// Report has failures and their groups, and the groups are the main table if grouping is requested.
+func (c *FailuresCmd) Report(views []*CfnFailuresView) *output.Report {
+	groups := c.GroupFailures(views)
+	report := &output.Report{Tables: []*output.Table{
+		newTable(c.toCsvViews(views)).toOutput(c.Name(), views),
+		newTable(c.toGroupCsvViews(groups)).toOutput(c.Name()+"-groups", groups),
+	}}
+	if c.group {
+		report.Main = 1
+		report.Aggregated = true
+	}
+	return report
+}
+
+// GroupFailures groups failures by normalized reasons, in descending order of counts.
+func (c *FailuresCmd) GroupFailures(views []*CfnFailuresView) []CfnFailureGroup {
+	groups := []CfnFailureGroup{}
+	indices := map[string]int{}
+	for _, view := range views {
+		if view.Failure.ReasonGroup == "" {
+			continue
+		}
+		i, ok := indices[view.Failure.ReasonGroup]
+		if !ok {
+			i = len(groups)
+			indices[view.Failure.ReasonGroup] = i
+			groups = append(groups, CfnFailureGroup{ReasonGroup: view.Failure.ReasonGroup})
+		}
+		groups[i].Count++
+		groups[i].Stacks = append(groups[i].Stacks, fmt.Sprintf("%s/%s/%s", view.AccountId, view.Region, view.StackName))
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Count > groups[j].Count })
+	return groups
+}
+
+func (c *FailuresCmd) GetGlobalViews() []*CfnFailuresView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, c.collected)
+
+	sortViews(globalViews, func(view *CfnFailuresView) []string {
+		return []string{view.AccountId, view.Region, view.StackName}
+	})
+
+	return globalViews
+}
+
+func (c *FailuresCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnFailuresView {
+	views := []*CfnFailuresView{}
+	c.logger.Info("get cfn failures", "accountId", accountConfig.Id, "region", region)
+
+	cfn := cloudformation.New(newSession(accountConfig, region))
+
+	matchedStacks, err := describeMatchedStacks(cfn, accountConfig.Filters)
+	if err != nil {
+		return append(views, &CfnFailuresView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Error:       err,
+		})
+	}
+
+	for _, matchedStack := range matchedStacks {
+		if !matchesAnyGlob(*matchedStack.StackStatus, FAILED_STACK_STATUS_PATTERNS) {
+			continue
+		}
+		failure, err := c.findRootCause(cfn, *matchedStack.StackId, 0)
+		views = append(views, &CfnFailuresView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			StackName:   *matchedStack.StackName,
+			StackStatus: *matchedStack.StackStatus,
+			Failure:     failure,
+			Error:       err,
+		})
+	}
+
+	return views
+}
+
+// findRootCause returns the first failed resource in the most recent operation of the stack.
+// if the resource is a nested stack, the root cause in the nested stack is returned instead.
+func (c *FailuresCmd) findRootCause(cfn cloudformationiface.CloudFormationAPI, stackId string, depth int) (CfnFailure, error) {
+	failure := CfnFailure{}
+
+	events, err := describeLastOperationEvents(cfn, stackId)
+	if err != nil {
+		return failure, err
+	}
+	if len(events) != 0 {
+		failure.OperationStartTime = formatTime(events[0].Timestamp)
+	}
+
+	for _, event := range events {
+		status := aws.StringValue(event.ResourceStatus)
+		reason := aws.StringValue(event.ResourceStatusReason)
+		if aws.StringValue(event.PhysicalResourceId) == stackId || !strings.HasSuffix(status, "_FAILED") {
+			continue
+		}
+		// resources cancelled because another resource failed are not root causes
+		if strings.Contains(strings.ToLower(reason), "cancelled") {
+			continue
+		}
+
+		if aws.StringValue(event.ResourceType) == "AWS::CloudFormation::Stack" && depth < MAX_NESTED_STACK_DEPTH {
+			nestedStackId := aws.StringValue(event.PhysicalResourceId)
+			if strings.HasPrefix(nestedStackId, "arn:") {
+				nestedFailure, err := c.findRootCause(cfn, nestedStackId, depth+1)
+				if err == nil && nestedFailure.LogicalId != "" {
+					if nestedFailure.NestedStackName == "" {
+						nestedFailure.NestedStackName = stackNameFromId(nestedStackId)
+					}
+					nestedFailure.OperationStartTime = failure.OperationStartTime
+					return nestedFailure, nil
+				}
+			}
+		}
+
+		failure.LogicalId = aws.StringValue(event.LogicalResourceId)
+		failure.PhysicalId = aws.StringValue(event.PhysicalResourceId)
+		failure.Type = aws.StringValue(event.ResourceType)
+		failure.Status = status
+		failure.StatusReason = reason
+		failure.Timestamp = formatTime(event.Timestamp)
+		failure.ReasonGroup = normalizeReason(reason)
+		return failure, nil
+	}
+
+	return failure, nil
+}
+
+// describeLastOperationEvents returns events of the most recent operation of the stack in chronological order.
+// the operation starts with a stack level CREATE, UPDATE, DELETE or IMPORT _IN_PROGRESS event.
+func describeLastOperationEvents(cfn cloudformationiface.CloudFormationAPI, stackId string) ([]*cloudformation.StackEvent, error) {
+	var events []*cloudformation.StackEvent
+	input := &cloudformation.DescribeStackEventsInput{
+		StackName: aws.String(stackId),
+	}
+	for {
+		describeEventsOutput, err := cfn.DescribeStackEvents(input)
+		if err != nil {
+			return nil, err
+		}
+		started := false
+		for _, event := range describeEventsOutput.StackEvents {
+			events = append(events, event)
+			if aws.StringValue(event.PhysicalResourceId) == stackId {
+				for _, status := range OPERATION_START_STATUSES {
+					if aws.StringValue(event.ResourceStatus) == status {
+						started = true
+					}
+				}
+			}
+			if started {
+				break
+			}
+		}
+		if started || describeEventsOutput.NextToken == nil {
+			break
+		}
+		input.NextToken = describeEventsOutput.NextToken
+	}
+
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
+
+// normalizeReason replaces ids, arns and numbers in the reason so that similar reasons are grouped together.
+func normalizeReason(reason string) string {
+	for _, normalizer := range reasonNormalizers {
+		reason = normalizer.pattern.ReplaceAllString(reason, normalizer.replacement)
+	}
+	return strings.TrimSpace(reason)
+}
@@ -0,0 +1,164 @@
+package subcommands
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	TEST_PARENT_STACK_ID = "arn:aws:cloudformation:ap-northeast-1:123456789012:stack/parent/11111111-1111-1111-1111-111111111111"
+	TEST_NESTED_STACK_ID = "arn:aws:cloudformation:ap-northeast-1:123456789012:stack/parent-Nested-ABC/22222222-2222-2222-2222-222222222222"
+)
+
+type mockFailuresClient struct {
+	cloudformationiface.CloudFormationAPI
+	// events of each stack in reverse chronological order
+	events map[string][]*cloudformation.StackEvent
+}
+
+func (m *mockFailuresClient) DescribeStackEvents(input *cloudformation.DescribeStackEventsInput) (*cloudformation.DescribeStackEventsOutput, error) {
+	return &cloudformation.DescribeStackEventsOutput{StackEvents: m.events[*input.StackName]}, nil
+}
+
+func newTestFailureEvent(stackId, logicalId, physicalId, resourceType, status, reason string) *cloudformation.StackEvent {
+	return &cloudformation.StackEvent{
+		StackId:              aws.String(stackId),
+		LogicalResourceId:    aws.String(logicalId),
+		PhysicalResourceId:   aws.String(physicalId),
+		ResourceType:         aws.String(resourceType),
+		ResourceStatus:       aws.String(status),
+		ResourceStatusReason: aws.String(reason),
+	}
+}
+
+func TestFailures_invalid(t *testing.T) {
+	c := getInvalidConfig(t, "")
+
+	cmd := FailuresCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 1, len(views))
+	assert.NotNil(t, views[0].Error, views[0])
+
+}
+
+func TestFailures_findRootCause(t *testing.T) {
+	cfn := &mockFailuresClient{events: map[string][]*cloudformation.StackEvent{
+		TEST_PARENT_STACK_ID: {
+			newTestFailureEvent(TEST_PARENT_STACK_ID, "parent", TEST_PARENT_STACK_ID, "AWS::CloudFormation::Stack", "UPDATE_ROLLBACK_COMPLETE", ""),
+			newTestFailureEvent(TEST_PARENT_STACK_ID, "Queue", "queue", "AWS::SQS::Queue", "UPDATE_FAILED", "Resource update cancelled"),
+			newTestFailureEvent(TEST_PARENT_STACK_ID, "Nested", TEST_NESTED_STACK_ID, "AWS::CloudFormation::Stack", "UPDATE_FAILED", "Embedded stack was not successfully updated"),
+			newTestFailureEvent(TEST_PARENT_STACK_ID, "parent", TEST_PARENT_STACK_ID, "AWS::CloudFormation::Stack", "UPDATE_IN_PROGRESS", "User Initiated"),
+			// failures of the previous operation are ignored
+			newTestFailureEvent(TEST_PARENT_STACK_ID, "Old", "old", "AWS::S3::Bucket", "CREATE_FAILED", "old failure"),
+		},
+		TEST_NESTED_STACK_ID: {
+			newTestFailureEvent(TEST_NESTED_STACK_ID, "Role", "role", "AWS::IAM::Role", "UPDATE_FAILED", "Resource update cancelled"),
+			newTestFailureEvent(TEST_NESTED_STACK_ID, "Bucket", "my-bucket", "AWS::S3::Bucket", "UPDATE_FAILED", "my-bucket already exists (Request ID: ABC123)"),
+			newTestFailureEvent(TEST_NESTED_STACK_ID, "parent-Nested-ABC", TEST_NESTED_STACK_ID, "AWS::CloudFormation::Stack", "UPDATE_IN_PROGRESS", ""),
+		},
+	}}
+
+	cmd := FailuresCmd{}
+	failure, err := cmd.findRootCause(cfn, TEST_PARENT_STACK_ID, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "Bucket", failure.LogicalId)
+	assert.Equal(t, "AWS::S3::Bucket", failure.Type)
+	assert.Equal(t, "parent-Nested-ABC", failure.NestedStackName)
+	assert.Equal(t, "my-bucket already exists (Request ID: <id>)", failure.ReasonGroup)
+}
+
+func TestFailures_GroupFailures(t *testing.T) {
+	assert.Equal(t,
+		normalizeReason("Role arn:aws:iam::123456789012:role/a is invalid (Request ID: 0a1b-2c)"),
+		normalizeReason("Role arn:aws:iam::210987654321:role/b is invalid (Request ID: 9z8y-7x)"),
+	)
+
+	cmd := FailuresCmd{}
+	groups := cmd.GroupFailures([]*CfnFailuresView{
+		{AccountId: "1", Region: "r", StackName: "a", Failure: CfnFailure{ReasonGroup: "x"}},
+		{AccountId: "1", Region: "r", StackName: "b", Failure: CfnFailure{ReasonGroup: "y"}},
+		{AccountId: "2", Region: "r", StackName: "c", Failure: CfnFailure{ReasonGroup: "y"}},
+		{AccountId: "2", Region: "r", StackName: "d"},
+	})
+	assert.Equal(t, 2, len(groups))
+	assert.Equal(t, "y", groups[0].ReasonGroup)
+	assert.Equal(t, 2, groups[0].Count)
+	assert.Equal(t, []string{"1/r/b", "2/r/c"}, groups[0].Stacks)
+}
+
+func TestFailures_findRootCause_selection(t *testing.T) {
+	const stackId = TEST_PARENT_STACK_ID
+	start := newTestFailureEvent(stackId, "parent", stackId, "AWS::CloudFormation::Stack", "UPDATE_IN_PROGRESS", "User Initiated")
+	stackFailed := newTestFailureEvent(stackId, "parent", stackId, "AWS::CloudFormation::Stack", "UPDATE_ROLLBACK_FAILED", "")
+	cmd := FailuresCmd{}
+
+	// the earliest failure of the operation is the root cause, and stack level failures are not
+	cfn := &mockFailuresClient{events: map[string][]*cloudformation.StackEvent{stackId: {
+		stackFailed,
+		newTestFailureEvent(stackId, "Second", "second", "AWS::S3::Bucket", "UPDATE_FAILED", "second failure"),
+		newTestFailureEvent(stackId, "First", "first", "AWS::SQS::Queue", "UPDATE_FAILED", "first failure"),
+		start,
+	}}}
+	failure, err := cmd.findRootCause(cfn, stackId, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "First", failure.LogicalId)
+	assert.Equal(t, "first failure", failure.StatusReason)
+
+	// operations with only cancelled resources have no root cause
+	cfn = &mockFailuresClient{events: map[string][]*cloudformation.StackEvent{stackId: {
+		stackFailed,
+		newTestFailureEvent(stackId, "Queue", "queue", "AWS::SQS::Queue", "UPDATE_FAILED", "Resource update cancelled"),
+		start,
+	}}}
+	failure, err = cmd.findRootCause(cfn, stackId, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "", failure.LogicalId)
+
+	// nested stacks without failures of their own, or without stack ids yet, are root causes themselves
+	cfn = &mockFailuresClient{events: map[string][]*cloudformation.StackEvent{
+		stackId: {
+			newTestFailureEvent(stackId, "Nested", TEST_NESTED_STACK_ID, "AWS::CloudFormation::Stack", "UPDATE_FAILED", "Embedded stack was not successfully updated"),
+			start,
+		},
+		TEST_NESTED_STACK_ID: {
+			newTestFailureEvent(TEST_NESTED_STACK_ID, "parent-Nested-ABC", TEST_NESTED_STACK_ID, "AWS::CloudFormation::Stack", "UPDATE_IN_PROGRESS", ""),
+		},
+	}}
+	failure, err = cmd.findRootCause(cfn, stackId, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "Nested", failure.LogicalId)
+	assert.Equal(t, "", failure.NestedStackName)
+
+	cfn = &mockFailuresClient{events: map[string][]*cloudformation.StackEvent{stackId: {
+		newTestFailureEvent(stackId, "Nested", "", "AWS::CloudFormation::Stack", "CREATE_FAILED", "Template format error"),
+		start,
+	}}}
+	failure, err = cmd.findRootCause(cfn, stackId, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "Nested", failure.LogicalId)
+	assert.Equal(t, "Template format error", failure.StatusReason)
+
+	// nested stacks are followed up to MAX_NESTED_STACK_DEPTH, even if they refer to each other
+	cfn = &mockFailuresClient{events: map[string][]*cloudformation.StackEvent{
+		stackId: {
+			newTestFailureEvent(stackId, "Nested", TEST_NESTED_STACK_ID, "AWS::CloudFormation::Stack", "UPDATE_FAILED", ""),
+			start,
+		},
+		TEST_NESTED_STACK_ID: {
+			newTestFailureEvent(TEST_NESTED_STACK_ID, "Parent", stackId, "AWS::CloudFormation::Stack", "UPDATE_FAILED", ""),
+			newTestFailureEvent(TEST_NESTED_STACK_ID, "parent-Nested-ABC", TEST_NESTED_STACK_ID, "AWS::CloudFormation::Stack", "UPDATE_IN_PROGRESS", ""),
+		},
+	}}
+	failure, err = cmd.findRootCause(cfn, stackId, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "Parent", failure.LogicalId)
+	assert.Equal(t, "parent-Nested-ABC", failure.NestedStackName)
+}
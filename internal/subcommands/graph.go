@@ -0,0 +1,601 @@
+package subcommands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/google/subcommands"
+	"golang.org/x/exp/slog"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+const (
+	GRAPH_NODE_KIND_STACK    = "stack"
+	GRAPH_NODE_KIND_STACKSET = "stackset"
+
+	GRAPH_EDGE_KIND_IMPORT   = "import"
+	GRAPH_EDGE_KIND_NESTED   = "nested"
+	GRAPH_EDGE_KIND_STACKSET = "stackset"
+)
+
+type CfnGraphNode struct {
+	Id          string
+	Kind        string
+	AccountId   string
+	AccountName string
+	Region      string
+	Name        string
+	// Matched is false for stacks which don't match the filters but are referred by matched ones
+	Matched bool
+	InCycle bool
+}
+
+type CfnGraphEdge struct {
+	From     string
+	To       string
+	Kind     string
+	Label    string
+	InCycle  bool
+	Dangling bool
+}
+
+type CfnGraphView struct {
+	AccountId   string
+	AccountName string
+	Region      string
+	Nodes       []CfnGraphNode
+	Edges       []CfnGraphEdge
+	Error       error
+}
+
+// CfnGraph is a directed graph of stack dependencies merged from all accounts and regions.
+type CfnGraph struct {
+	Nodes  []CfnGraphNode
+	Edges  []CfnGraphEdge
+	Errors []string
+}
+
+type GraphCmd struct {
+	subcommands.Command
+	configFilePath    string
+	outFilePath       string
+	format            string
+	verbose           bool
+	root              string
+	depth             int
+	highlightCycles   bool
+	highlightDangling bool
+	logger            *slog.Logger
+	config            *config.CfnGlobalViewsConfig
+}
+
+func (*GraphCmd) Name() string {
+	return "graph"
+}
+func (*GraphCmd) Synopsis() string {
+	return "draw cfn stack dependencies as a graph"
+}
+func (*GraphCmd) Usage() string {
+	return "graph -c path/to/config.yaml [-f dot|mermaid|json] [-root StackName -depth 2] [-highlight-cycles] [-highlight-dangling]"
+}
+func (c *GraphCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
+	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
+	f.StringVar(&c.format, "f", "dot", "output data format [dot, mermaid, json] (default is dot)")
+	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+	f.StringVar(&c.root, "root", "", "if set, only draw stacks reachable from stacks with this name")
+	f.IntVar(&c.depth, "depth", 0, "max depth from the root stacks. 0 means unlimited")
+	f.BoolVar(&c.highlightCycles, "highlight-cycles", false, "if set, highlight stacks and edges in dependency cycles")
+	f.BoolVar(&c.highlightDangling, "highlight-dangling", false, "if set, highlight imports from or to stacks which don't match the filters")
+}
+
+func (c *GraphCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, []string{"dot", "mermaid", "json"}, c.verbose, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return subcommands.ExitFailure
+	}
+
+	graph := c.GetGraph(c.GetGlobalViews())
+
+	switch c.format {
+	case "dot":
+		err = c.DumpDot(graph)
+	case "mermaid":
+		err = c.DumpMermaid(graph)
+	case "json":
+		err = output.WriteJson(c.outFilePath, graph)
+	}
+	return exitStatus(c.logger, err)
+}
+
+func (c *GraphCmd) GetGlobalViews() []*CfnGraphView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, nil)
+
+	sortViews(globalViews, func(view *CfnGraphView) []string {
+		return []string{view.AccountId, view.Region}
+	})
+
+	return globalViews
+}
+
+func (c *GraphCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnGraphView {
+	c.logger.Info("get cfn graph", "accountId", accountConfig.Id, "region", region)
+
+	view := &CfnGraphView{
+		AccountId:   accountConfig.Id,
+		AccountName: accountConfig.Name,
+		Region:      region,
+	}
+	cfn := cloudformation.New(newSession(accountConfig, region))
+
+	// describe all stacks to resolve importing stack names, and add matched ones as nodes
+	stacks, err := describeMatchedStacks(cfn, config.Filters{})
+	if err != nil {
+		view.Error = err
+		return []*CfnGraphView{view}
+	}
+	stackIds := map[string]string{}
+	matchedStackIds := map[string]bool{}
+	stackNode := func(stackId, accountId, accountName, region string, matched bool) CfnGraphNode {
+		return CfnGraphNode{
+			Id:          stackId,
+			Kind:        GRAPH_NODE_KIND_STACK,
+			AccountId:   accountId,
+			AccountName: accountName,
+			Region:      region,
+			Name:        stackNameFromId(stackId),
+			Matched:     matched,
+		}
+	}
+	for _, stack := range stacks {
+		stackIds[*stack.StackName] = *stack.StackId
+		if !matchesFilters(stack, accountConfig.Filters) {
+			continue
+		}
+		matchedStackIds[*stack.StackId] = true
+		view.Nodes = append(view.Nodes, stackNode(*stack.StackId, accountConfig.Id, accountConfig.Name, region, true))
+		// nested stacks
+		if stack.ParentId != nil {
+			view.Edges = append(view.Edges, CfnGraphEdge{
+				From: *stack.ParentId,
+				To:   *stack.StackId,
+				Kind: GRAPH_EDGE_KIND_NESTED,
+			})
+		}
+	}
+	for _, stack := range stacks {
+		if stack.ParentId != nil && matchedStackIds[*stack.StackId] && !matchedStackIds[*stack.ParentId] {
+			view.Nodes = append(view.Nodes, stackNode(*stack.ParentId, accountConfig.Id, accountConfig.Name, region, false))
+		}
+	}
+
+	// imports between stacks where either the exporting or importing stack matches the filters
+	exports, err := listExports(cfn)
+	if err != nil {
+		view.Error = err
+		return []*CfnGraphView{view}
+	}
+	for _, export := range exports {
+		exportingStackId := aws.StringValue(export.ExportingStackId)
+		importingStacks, err := listImports(cfn, *export.Name)
+		if err != nil {
+			view.Error = err
+			continue
+		}
+		for _, importingStack := range importingStacks {
+			importingStackId, ok := stackIds[importingStack]
+			if !ok {
+				importingStackId = fmt.Sprintf("%s/%s/%s", accountConfig.Id, region, importingStack)
+			}
+			if !matchedStackIds[exportingStackId] && !matchedStackIds[importingStackId] {
+				continue
+			}
+			for _, stackId := range []string{exportingStackId, importingStackId} {
+				if !matchedStackIds[stackId] {
+					node := stackNode(stackId, accountConfig.Id, accountConfig.Name, region, false)
+					if stackId == importingStackId {
+						node.Name = importingStack
+					}
+					view.Nodes = append(view.Nodes, node)
+				}
+			}
+			view.Edges = append(view.Edges, CfnGraphEdge{
+				From:  importingStackId,
+				To:    exportingStackId,
+				Kind:  GRAPH_EDGE_KIND_IMPORT,
+				Label: *export.Name,
+			})
+		}
+	}
+
+	// stackset memberships
+	stackSets, err := listMatchedStackSets(cfn, accountConfig.Filters)
+	if err != nil {
+		view.Error = err
+		return []*CfnGraphView{view}
+	}
+	for _, stackSet := range stackSets {
+		stackSetNodeId := aws.StringValue(stackSet.StackSetId)
+		view.Nodes = append(view.Nodes, CfnGraphNode{
+			Id:          stackSetNodeId,
+			Kind:        GRAPH_NODE_KIND_STACKSET,
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Name:        *stackSet.StackSetName,
+			Matched:     true,
+		})
+		input := &cloudformation.ListStackInstancesInput{StackSetName: stackSet.StackSetName}
+		for {
+			listStackInstancesOutput, err := cfn.ListStackInstances(input)
+			if err != nil {
+				view.Error = err
+				break
+			}
+			for _, instance := range listStackInstancesOutput.Summaries {
+				if instance.StackId == nil {
+					continue
+				}
+				// instance stacks in other accounts are merged with matched ones in GetGraph
+				view.Nodes = append(view.Nodes, stackNode(*instance.StackId, aws.StringValue(instance.Account), "", aws.StringValue(instance.Region), false))
+				view.Edges = append(view.Edges, CfnGraphEdge{
+					From: stackSetNodeId,
+					To:   *instance.StackId,
+					Kind: GRAPH_EDGE_KIND_STACKSET,
+				})
+			}
+			if listStackInstancesOutput.NextToken == nil {
+				break
+			}
+			input.NextToken = listStackInstancesOutput.NextToken
+		}
+	}
+
+	return []*CfnGraphView{view}
+}
+
+// GetGraph merges views into a graph, limits it to the root stacks and depth,
+// and marks cycles and dangling imports.
+func (c *GraphCmd) GetGraph(views []*CfnGraphView) *CfnGraph {
+	graph := &CfnGraph{Errors: []string{}}
+
+	nodes := map[string]*CfnGraphNode{}
+	nodeIds := []string{}
+	edges := map[string]*CfnGraphEdge{}
+	edgeKeys := []string{}
+	for _, view := range views {
+		if view.Error != nil {
+			graph.Errors = append(graph.Errors, fmt.Sprintf("%s/%s: %s", view.AccountId, view.Region, view.Error.Error()))
+		}
+		for i := range view.Nodes {
+			node := view.Nodes[i]
+			if existing, ok := nodes[node.Id]; ok {
+				// prefer nodes of matched stacks, which have account names
+				if !existing.Matched && node.Matched {
+					*existing = node
+				}
+				continue
+			}
+			nodes[node.Id] = &node
+			nodeIds = append(nodeIds, node.Id)
+		}
+		for i := range view.Edges {
+			edge := view.Edges[i]
+			key := strings.Join([]string{edge.From, edge.To, edge.Kind, edge.Label}, "\x00")
+			if _, ok := edges[key]; ok {
+				continue
+			}
+			edges[key] = &edge
+			edgeKeys = append(edgeKeys, key)
+		}
+	}
+
+	// limit nodes to those within the depth from the root stacks, following edges in both directions
+	if c.root != "" {
+		neighbors := map[string][]string{}
+		for _, edge := range edges {
+			neighbors[edge.From] = append(neighbors[edge.From], edge.To)
+			neighbors[edge.To] = append(neighbors[edge.To], edge.From)
+		}
+		depths := map[string]int{}
+		queue := []string{}
+		for _, id := range nodeIds {
+			if nodes[id].Kind == GRAPH_NODE_KIND_STACK && nodes[id].Name == c.root {
+				depths[id] = 0
+				queue = append(queue, id)
+			}
+		}
+		for len(queue) != 0 {
+			id := queue[0]
+			queue = queue[1:]
+			if c.depth > 0 && depths[id] >= c.depth {
+				continue
+			}
+			for _, neighbor := range neighbors[id] {
+				if _, visited := depths[neighbor]; !visited {
+					depths[neighbor] = depths[id] + 1
+					queue = append(queue, neighbor)
+				}
+			}
+		}
+		for _, id := range nodeIds {
+			if _, ok := depths[id]; !ok {
+				delete(nodes, id)
+			}
+		}
+	}
+
+	for _, id := range nodeIds {
+		if node, ok := nodes[id]; ok {
+			graph.Nodes = append(graph.Nodes, *node)
+		}
+	}
+	for _, key := range edgeKeys {
+		edge := edges[key]
+		from, fromOk := nodes[edge.From]
+		to, toOk := nodes[edge.To]
+		if !fromOk || !toOk {
+			continue
+		}
+		edge.Dangling = edge.Kind == GRAPH_EDGE_KIND_IMPORT && (!from.Matched || !to.Matched)
+		graph.Edges = append(graph.Edges, *edge)
+	}
+
+	markCycles(graph)
+
+	sort.SliceStable(graph.Nodes, func(i, j int) bool {
+		if graph.Nodes[i].AccountId != graph.Nodes[j].AccountId {
+			return graph.Nodes[i].AccountId < graph.Nodes[j].AccountId
+		}
+		if graph.Nodes[i].Region != graph.Nodes[j].Region {
+			return graph.Nodes[i].Region < graph.Nodes[j].Region
+		}
+		return graph.Nodes[i].Name < graph.Nodes[j].Name
+	})
+
+	return graph
+}
+
+// markCycles marks nodes and edges in strongly connected components with more than one node
+// (or a node with an edge to itself), using tarjan's algorithm.
+func markCycles(graph *CfnGraph) {
+	successors := map[string][]string{}
+	for _, edge := range graph.Edges {
+		successors[edge.From] = append(successors[edge.From], edge.To)
+	}
+
+	index := 0
+	indices := map[string]int{}
+	lowlinks := map[string]int{}
+	onStack := map[string]bool{}
+	stack := []string{}
+	components := map[string]int{}
+	componentSizes := []int{}
+
+	var connect func(id string)
+	connect = func(id string) {
+		indices[id] = index
+		lowlinks[id] = index
+		index++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, successor := range successors[id] {
+			if _, visited := indices[successor]; !visited {
+				connect(successor)
+				if lowlinks[successor] < lowlinks[id] {
+					lowlinks[id] = lowlinks[successor]
+				}
+			} else if onStack[successor] && indices[successor] < lowlinks[id] {
+				lowlinks[id] = indices[successor]
+			}
+		}
+
+		if lowlinks[id] == indices[id] {
+			size := 0
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				components[top] = len(componentSizes)
+				size++
+				if top == id {
+					break
+				}
+			}
+			componentSizes = append(componentSizes, size)
+		}
+	}
+	for _, node := range graph.Nodes {
+		if _, visited := indices[node.Id]; !visited {
+			connect(node.Id)
+		}
+	}
+
+	inCycle := map[string]bool{}
+	for i := range graph.Edges {
+		edge := &graph.Edges[i]
+		if components[edge.From] == components[edge.To] && (edge.From == edge.To || componentSizes[components[edge.From]] > 1) {
+			edge.InCycle = true
+			inCycle[edge.From] = true
+			inCycle[edge.To] = true
+		}
+	}
+	for i := range graph.Nodes {
+		graph.Nodes[i].InCycle = inCycle[graph.Nodes[i].Id]
+	}
+}
+
+// groupNodes groups node indices by account and region in the order of nodes.
+func groupNodes(nodes []CfnGraphNode) ([]string, map[string][]int) {
+	groups := []string{}
+	members := map[string][]int{}
+	for i, node := range nodes {
+		group := node.AccountId + "/" + node.Region
+		if _, ok := members[group]; !ok {
+			groups = append(groups, group)
+		}
+		members[group] = append(members[group], i)
+	}
+	return groups, members
+}
+
+func groupLabel(node CfnGraphNode) string {
+	if node.AccountName != "" {
+		return fmt.Sprintf("%s (%s) %s", node.AccountName, node.AccountId, node.Region)
+	}
+	return fmt.Sprintf("%s %s", node.AccountId, node.Region)
+}
+
+func (c *GraphCmd) DumpDot(graph *CfnGraph) error {
+	writer, err := output.OpenWriter(c.outFilePath)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	return c.writeDot(writer, graph)
+}
+
+func (c *GraphCmd) writeDot(w io.Writer, graph *CfnGraph) error {
+	quote := func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+
+	lines := []string{"digraph cfn {", "\trankdir=LR;", "\tnode [shape=box];"}
+	groups, members := groupNodes(graph.Nodes)
+	for i, group := range groups {
+		lines = append(lines, fmt.Sprintf("\tsubgraph cluster_%d {", i))
+		lines = append(lines, fmt.Sprintf("\t\tlabel=%s;", quote(groupLabel(graph.Nodes[members[group][0]]))))
+		for _, n := range members[group] {
+			node := graph.Nodes[n]
+			attrs := []string{"label=" + quote(node.Name)}
+			if node.Kind == GRAPH_NODE_KIND_STACKSET {
+				attrs = append(attrs, "shape=folder")
+			}
+			if !node.Matched {
+				attrs = append(attrs, "style=dashed")
+			}
+			if c.highlightCycles && node.InCycle {
+				attrs = append(attrs, "color=red")
+			}
+			lines = append(lines, fmt.Sprintf("\t\t%s [%s];", quote(node.Id), strings.Join(attrs, ", ")))
+		}
+		lines = append(lines, "\t}")
+	}
+	for _, edge := range graph.Edges {
+		attrs := []string{}
+		if edge.Label != "" {
+			attrs = append(attrs, "label="+quote(edge.Label))
+		}
+		switch edge.Kind {
+		case GRAPH_EDGE_KIND_NESTED:
+			attrs = append(attrs, "arrowhead=diamond")
+		case GRAPH_EDGE_KIND_STACKSET:
+			attrs = append(attrs, "style=dotted")
+		}
+		if c.highlightDangling && edge.Dangling {
+			attrs = append(attrs, "color=orange", "style=dashed")
+		}
+		if c.highlightCycles && edge.InCycle {
+			attrs = append(attrs, "color=red", "penwidth=2")
+		}
+		lines = append(lines, fmt.Sprintf("\t%s -> %s [%s];", quote(edge.From), quote(edge.To), strings.Join(attrs, ", ")))
+	}
+	for _, e := range graph.Errors {
+		lines = append(lines, fmt.Sprintf("\t// error: %s", e))
+	}
+	lines = append(lines, "}", "")
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
+
+func (c *GraphCmd) DumpMermaid(graph *CfnGraph) error {
+	writer, err := output.OpenWriter(c.outFilePath)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	return c.writeMermaid(writer, graph)
+}
+
+func (c *GraphCmd) writeMermaid(w io.Writer, graph *CfnGraph) error {
+	escape := func(s string) string {
+		return strings.ReplaceAll(s, `"`, "#quot;")
+	}
+	// mermaid node ids must be simple, so nodes are numbered
+	nodeIds := map[string]string{}
+	for i, node := range graph.Nodes {
+		nodeIds[node.Id] = fmt.Sprintf("n%d", i)
+	}
+
+	lines := []string{"flowchart LR"}
+	groups, members := groupNodes(graph.Nodes)
+	cycleNodes := []string{}
+	unmatchedNodes := []string{}
+	for i, group := range groups {
+		lines = append(lines, fmt.Sprintf("\tsubgraph g%d [\"%s\"]", i, escape(groupLabel(graph.Nodes[members[group][0]]))))
+		for _, n := range members[group] {
+			node := graph.Nodes[n]
+			if node.Kind == GRAPH_NODE_KIND_STACKSET {
+				lines = append(lines, fmt.Sprintf("\t\t%s[/\"%s\"/]", nodeIds[node.Id], escape(node.Name)))
+			} else {
+				lines = append(lines, fmt.Sprintf("\t\t%s[\"%s\"]", nodeIds[node.Id], escape(node.Name)))
+			}
+			if c.highlightCycles && node.InCycle {
+				cycleNodes = append(cycleNodes, nodeIds[node.Id])
+			}
+			if !node.Matched {
+				unmatchedNodes = append(unmatchedNodes, nodeIds[node.Id])
+			}
+		}
+		lines = append(lines, "\tend")
+	}
+	linkStyles := []string{}
+	for i, edge := range graph.Edges {
+		arrow := "-->"
+		switch edge.Kind {
+		case GRAPH_EDGE_KIND_NESTED:
+			arrow = "--o"
+		case GRAPH_EDGE_KIND_STACKSET:
+			arrow = "-.->"
+		}
+		if edge.Label != "" {
+			lines = append(lines, fmt.Sprintf("\t%s %s|\"%s\"| %s", nodeIds[edge.From], arrow, escape(edge.Label), nodeIds[edge.To]))
+		} else {
+			lines = append(lines, fmt.Sprintf("\t%s %s %s", nodeIds[edge.From], arrow, nodeIds[edge.To]))
+		}
+		if c.highlightCycles && edge.InCycle {
+			linkStyles = append(linkStyles, fmt.Sprintf("\tlinkStyle %d stroke:red,stroke-width:2px", i))
+		} else if c.highlightDangling && edge.Dangling {
+			linkStyles = append(linkStyles, fmt.Sprintf("\tlinkStyle %d stroke:orange,stroke-dasharray:4", i))
+		}
+	}
+	lines = append(lines, linkStyles...)
+	if len(unmatchedNodes) != 0 {
+		lines = append(lines, "\tclassDef unmatched stroke-dasharray:4", fmt.Sprintf("\tclass %s unmatched", strings.Join(unmatchedNodes, ",")))
+	}
+	if len(cycleNodes) != 0 {
+		lines = append(lines, "\tclassDef cycle stroke:red,stroke-width:2px", fmt.Sprintf("\tclass %s cycle", strings.Join(cycleNodes, ",")))
+	}
+	for _, e := range graph.Errors {
+		lines = append(lines, fmt.Sprintf("\t%%%% error: %s", e))
+	}
+	lines = append(lines, "")
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
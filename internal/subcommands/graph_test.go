@@ -0,0 +1,184 @@
+package subcommands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraph_invalid(t *testing.T) {
+	c := getInvalidConfig(t, "")
+
+	cmd := GraphCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 1, len(views))
+	assert.NotNil(t, views[0].Error, views[0])
+
+	graph := cmd.GetGraph(views)
+	assert.Equal(t, 0, len(graph.Nodes))
+	assert.Equal(t, 1, len(graph.Errors))
+}
+
+func testGraphViews() []*CfnGraphView {
+	node := func(id string, matched bool) CfnGraphNode {
+		return CfnGraphNode{Id: id, Kind: GRAPH_NODE_KIND_STACK, AccountId: "123456789012", Region: "ap-northeast-1", Name: id, Matched: matched}
+	}
+	return []*CfnGraphView{
+		{
+			AccountId: "123456789012",
+			Region:    "ap-northeast-1",
+			Nodes:     []CfnGraphNode{node("a", true), node("b", true), node("c", true), node("d", true), node("external", false)},
+			Edges: []CfnGraphEdge{
+				{From: "a", To: "b", Kind: GRAPH_EDGE_KIND_IMPORT, Label: "b-export"},
+				{From: "b", To: "a", Kind: GRAPH_EDGE_KIND_IMPORT, Label: "a-export"},
+				{From: "b", To: "c", Kind: GRAPH_EDGE_KIND_NESTED},
+				{From: "c", To: "d", Kind: GRAPH_EDGE_KIND_IMPORT, Label: "d-export"},
+				{From: "d", To: "external", Kind: GRAPH_EDGE_KIND_IMPORT, Label: "external-export"},
+			},
+		},
+		{
+			AccountId: "210987654321",
+			Region:    "ap-northeast-1",
+			// the same stack found from another account is merged
+			Nodes: []CfnGraphNode{node("external", false)},
+		},
+	}
+}
+
+func TestGraph_cycles_and_dangling(t *testing.T) {
+	cmd := GraphCmd{}
+	graph := cmd.GetGraph(testGraphViews())
+
+	assert.Equal(t, 5, len(graph.Nodes))
+	assert.Equal(t, 5, len(graph.Edges))
+	inCycle := map[string]bool{}
+	for _, node := range graph.Nodes {
+		inCycle[node.Id] = node.InCycle
+	}
+	assert.Equal(t, map[string]bool{"a": true, "b": true, "c": false, "d": false, "external": false}, inCycle)
+	for _, edge := range graph.Edges {
+		assert.Equal(t, edge.Label == "a-export" || edge.Label == "b-export", edge.InCycle, edge)
+		assert.Equal(t, edge.Label == "external-export", edge.Dangling, edge)
+	}
+}
+
+func TestGraph_root_and_depth(t *testing.T) {
+	cmd := GraphCmd{root: "c", depth: 1}
+	graph := cmd.GetGraph(testGraphViews())
+
+	names := []string{}
+	for _, node := range graph.Nodes {
+		names = append(names, node.Name)
+	}
+	assert.ElementsMatch(t, []string{"b", "c", "d"}, names)
+	assert.Equal(t, 2, len(graph.Edges))
+
+	cmd = GraphCmd{root: "c"}
+	graph = cmd.GetGraph(testGraphViews())
+	assert.Equal(t, 5, len(graph.Nodes))
+}
+
+func TestGraph_markCycles(t *testing.T) {
+	graph := &CfnGraph{}
+	for _, id := range []string{"w", "x", "y", "z", "self", "p", "q", "end"} {
+		graph.Nodes = append(graph.Nodes, CfnGraphNode{Id: id})
+	}
+	edge := func(from, to string) CfnGraphEdge {
+		return CfnGraphEdge{From: from, To: to, Label: from + "->" + to}
+	}
+	graph.Edges = []CfnGraphEdge{
+		// w leads into the cycle x -> y -> z -> x but isn't part of it
+		edge("w", "x"), edge("x", "y"), edge("y", "z"), edge("z", "x"),
+		// z leaves the cycle to end
+		edge("z", "end"),
+		// a stack exporting to itself
+		edge("self", "self"),
+		// another cycle disjoint from the first one
+		edge("p", "q"), edge("q", "p"),
+	}
+
+	markCycles(graph)
+
+	inCycle := map[string]bool{}
+	for _, node := range graph.Nodes {
+		inCycle[node.Id] = node.InCycle
+	}
+	assert.Equal(t, map[string]bool{
+		"w": false, "x": true, "y": true, "z": true, "self": true, "p": true, "q": true, "end": false,
+	}, inCycle)
+	edgeInCycle := map[string]bool{}
+	for _, edge := range graph.Edges {
+		edgeInCycle[edge.Label] = edge.InCycle
+	}
+	assert.Equal(t, map[string]bool{
+		"w->x": false, "x->y": true, "y->z": true, "z->x": true, "z->end": false,
+		"self->self": true, "p->q": true, "q->p": true,
+	}, edgeInCycle)
+}
+
+func TestGraph_depth_limits(t *testing.T) {
+	names := func(graph *CfnGraph) []string {
+		names := []string{}
+		for _, node := range graph.Nodes {
+			names = append(names, node.Name)
+		}
+		return names
+	}
+
+	// depth 0 means unlimited
+	cmd := GraphCmd{root: "a", depth: 0}
+	graph := cmd.GetGraph(testGraphViews())
+	assert.Equal(t, 5, len(graph.Nodes))
+
+	cmd = GraphCmd{root: "d", depth: 1}
+	graph = cmd.GetGraph(testGraphViews())
+	assert.ElementsMatch(t, []string{"c", "d", "external"}, names(graph))
+	// edges are kept only between nodes within the depth
+	assert.Equal(t, 2, len(graph.Edges))
+	for _, node := range graph.Nodes {
+		assert.False(t, node.InCycle, node)
+	}
+
+	// the cycle between a and b is kept only if both of them are within the depth
+	cmd = GraphCmd{root: "c", depth: 2}
+	graph = cmd.GetGraph(testGraphViews())
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d", "external"}, names(graph))
+	for _, node := range graph.Nodes {
+		assert.Equal(t, node.Name == "a" || node.Name == "b", node.InCycle, node)
+	}
+	cmd = GraphCmd{root: "c", depth: 1}
+	graph = cmd.GetGraph(testGraphViews())
+	for _, node := range graph.Nodes {
+		assert.False(t, node.InCycle, node)
+	}
+
+	// unknown roots leave nothing
+	cmd = GraphCmd{root: "not-exist"}
+	graph = cmd.GetGraph(testGraphViews())
+	assert.Equal(t, 0, len(graph.Nodes))
+	assert.Equal(t, 0, len(graph.Edges))
+}
+
+func TestGraph_dump(t *testing.T) {
+	cmd := GraphCmd{highlightCycles: true, highlightDangling: true}
+	graph := cmd.GetGraph(testGraphViews())
+
+	dot := bytes.Buffer{}
+	err := cmd.writeDot(&dot, graph)
+	assert.Nil(t, err)
+	assert.Contains(t, dot.String(), "digraph cfn {")
+	assert.Contains(t, dot.String(), `"a" -> "b" [label="b-export", color=red, penwidth=2];`)
+	assert.Contains(t, dot.String(), `"d" -> "external" [label="external-export", color=orange, style=dashed];`)
+
+	mermaid := bytes.Buffer{}
+	err = cmd.writeMermaid(&mermaid, graph)
+	assert.Nil(t, err)
+	assert.Contains(t, mermaid.String(), "flowchart LR")
+	assert.Contains(t, mermaid.String(), `n0 -->|"b-export"| n1`)
+	assert.Contains(t, mermaid.String(), "class n0,n1 cycle")
+}
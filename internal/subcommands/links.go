@@ -0,0 +1,140 @@
+package subcommands
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var (
+	// arn formats of resource types whose physical ids are not arns.
+	// {partition}, {region}, {account} and {id} are replaced. iam resources are assumed to have the default path
+	RESOURCE_ARN_FORMATS = map[string]string{
+		"AWS::ApiGateway::RestApi":      "arn:{partition}:apigateway:{region}::/restapis/{id}",
+		"AWS::CloudFront::Distribution": "arn:{partition}:cloudfront::{account}:distribution/{id}",
+		"AWS::CloudWatch::Alarm":        "arn:{partition}:cloudwatch:{region}:{account}:alarm:{id}",
+		"AWS::DynamoDB::Table":          "arn:{partition}:dynamodb:{region}:{account}:table/{id}",
+		"AWS::EC2::Instance":            "arn:{partition}:ec2:{region}:{account}:instance/{id}",
+		"AWS::EC2::InternetGateway":     "arn:{partition}:ec2:{region}:{account}:internet-gateway/{id}",
+		"AWS::EC2::NatGateway":          "arn:{partition}:ec2:{region}:{account}:natgateway/{id}",
+		"AWS::EC2::RouteTable":          "arn:{partition}:ec2:{region}:{account}:route-table/{id}",
+		"AWS::EC2::SecurityGroup":       "arn:{partition}:ec2:{region}:{account}:security-group/{id}",
+		"AWS::EC2::Subnet":              "arn:{partition}:ec2:{region}:{account}:subnet/{id}",
+		"AWS::EC2::VPC":                 "arn:{partition}:ec2:{region}:{account}:vpc/{id}",
+		"AWS::EC2::VPCEndpoint":         "arn:{partition}:ec2:{region}:{account}:vpc-endpoint/{id}",
+		"AWS::ECR::Repository":          "arn:{partition}:ecr:{region}:{account}:repository/{id}",
+		"AWS::ECS::Cluster":             "arn:{partition}:ecs:{region}:{account}:cluster/{id}",
+		"AWS::Events::Rule":             "arn:{partition}:events:{region}:{account}:rule/{id}",
+		"AWS::IAM::Group":               "arn:{partition}:iam::{account}:group/{id}",
+		"AWS::IAM::InstanceProfile":     "arn:{partition}:iam::{account}:instance-profile/{id}",
+		"AWS::IAM::Role":                "arn:{partition}:iam::{account}:role/{id}",
+		"AWS::IAM::User":                "arn:{partition}:iam::{account}:user/{id}",
+		"AWS::KMS::Key":                 "arn:{partition}:kms:{region}:{account}:key/{id}",
+		"AWS::Lambda::Function":         "arn:{partition}:lambda:{region}:{account}:function:{id}",
+		"AWS::Logs::LogGroup":           "arn:{partition}:logs:{region}:{account}:log-group:{id}",
+		"AWS::RDS::DBCluster":           "arn:{partition}:rds:{region}:{account}:cluster:{id}",
+		"AWS::RDS::DBInstance":          "arn:{partition}:rds:{region}:{account}:db:{id}",
+		"AWS::S3::Bucket":               "arn:{partition}:s3:::{id}",
+		"AWS::SQS::Queue":               "arn:{partition}:sqs:{region}:{account}:{id}",
+		"AWS::SSM::Parameter":           "arn:{partition}:ssm:{region}:{account}:parameter/{id}",
+	}
+
+	// console url paths of resource types, appended to the console url of the region.
+	// {region}, {account}, {id} and {arn} are replaced, and {id} and {arn} are query escaped
+	RESOURCE_CONSOLE_PATHS = map[string]string{
+		"AWS::CloudFormation::Stack": "/cloudformation/home?region={region}#/stacks/stackinfo?stackId={arn}",
+		"AWS::DynamoDB::Table":       "/dynamodbv2/home?region={region}#table?name={id}",
+		"AWS::EC2::Instance":         "/ec2/home?region={region}#InstanceDetails:instanceId={id}",
+		"AWS::EC2::SecurityGroup":    "/ec2/home?region={region}#SecurityGroup:groupId={id}",
+		"AWS::EC2::Subnet":           "/vpc/home?region={region}#SubnetDetails:subnetId={id}",
+		"AWS::EC2::VPC":              "/vpc/home?region={region}#VpcDetails:VpcId={id}",
+		"AWS::ECR::Repository":       "/ecr/repositories/private/{account}/{id}?region={region}",
+		"AWS::IAM::Role":             "/iam/home#/roles/{id}",
+		"AWS::IAM::User":             "/iam/home#/users/{id}",
+		"AWS::KMS::Key":              "/kms/home?region={region}#/kms/keys/{id}",
+		"AWS::Lambda::Function":      "/lambda/home?region={region}#/functions/{id}",
+		"AWS::RDS::DBInstance":       "/rds/home?region={region}#database:id={id}",
+		"AWS::S3::Bucket":            "/s3/buckets/{id}?region={region}",
+		"AWS::SNS::Topic":            "/sns/v3/home?region={region}#/topic/{arn}",
+		"AWS::SQS::Queue":            "/sqs/v3/home?region={region}#/queues/{id}",
+	}
+)
+
+// arnPartition returns the partition of the region such as aws-cn for cn-north-1.
+func arnPartition(region string) string {
+	if strings.HasPrefix(region, "cn-") {
+		return "aws-cn"
+	}
+	if strings.HasPrefix(region, "us-gov-") {
+		return "aws-us-gov"
+	}
+	return "aws"
+}
+
+// consoleUrl returns the url of the aws management console for the region.
+func consoleUrl(region string) string {
+	switch arnPartition(region) {
+	case "aws-cn":
+		return fmt.Sprintf("https://%s.console.amazonaws.cn", region)
+	case "aws-us-gov":
+		return fmt.Sprintf("https://%s.console.amazonaws-us-gov.com", region)
+	}
+	return fmt.Sprintf("https://%s.console.aws.amazon.com", region)
+}
+
+// stackConsoleUrl returns the console url of the stack info page.
+func stackConsoleUrl(region, stackId string) string {
+	if stackId == "" {
+		return ""
+	}
+	return resourceConsoleUrl("", region, CfnResource{Type: "AWS::CloudFormation::Stack", PhysicalId: stackId})
+}
+
+// resourceArn returns the arn of the resource. physical ids which are already arns are returned as they are,
+// and an empty string is returned if the resource type has no known arn formats.
+func resourceArn(accountId, region string, resource CfnResource) string {
+	if resource.PhysicalId == "" {
+		return ""
+	}
+	if strings.HasPrefix(resource.PhysicalId, "arn:") {
+		return resource.PhysicalId
+	}
+	format, ok := RESOURCE_ARN_FORMATS[resource.Type]
+	if !ok {
+		return ""
+	}
+	id := resource.PhysicalId
+	switch resource.Type {
+	case "AWS::SQS::Queue":
+		// physical ids of queues are their urls ending with queue names
+		id = id[strings.LastIndex(id, "/")+1:]
+	case "AWS::SSM::Parameter":
+		id = strings.TrimPrefix(id, "/")
+	}
+	return strings.NewReplacer(
+		"{partition}", arnPartition(region), "{region}", region, "{account}", accountId, "{id}", id,
+	).Replace(format)
+}
+
+// resourceConsoleUrl returns the console url of the resource, or an empty string if the resource type
+// has no known console pages.
+func resourceConsoleUrl(accountId, region string, resource CfnResource) string {
+	path, ok := RESOURCE_CONSOLE_PATHS[resource.Type]
+	if !ok || resource.PhysicalId == "" {
+		return ""
+	}
+	return consoleUrl(region) + strings.NewReplacer(
+		"{region}", region,
+		"{account}", accountId,
+		"{id}", url.QueryEscape(resource.PhysicalId),
+		"{arn}", url.QueryEscape(resourceArn(accountId, region, resource)),
+	).Replace(path)
+}
+
+// setResourceLinks sets arns and console urls of the resources.
+func setResourceLinks(accountId, region string, resources []CfnResource) {
+	for i := range resources {
+		resources[i].Arn = resourceArn(accountId, region, resources[i])
+		resources[i].ConsoleUrl = resourceConsoleUrl(accountId, region, resources[i])
+	}
+}
@@ -0,0 +1,71 @@
+package subcommands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinks_resourceArn(t *testing.T) {
+	for _, c := range []struct {
+		region   string
+		resource CfnResource
+		arn      string
+	}{
+		{"ap-northeast-1", CfnResource{Type: "AWS::S3::Bucket", PhysicalId: "my-bucket"}, "arn:aws:s3:::my-bucket"},
+		{"ap-northeast-1", CfnResource{Type: "AWS::EC2::SecurityGroup", PhysicalId: "sg-0123"}, "arn:aws:ec2:ap-northeast-1:123456789012:security-group/sg-0123"},
+		{"cn-north-1", CfnResource{Type: "AWS::Lambda::Function", PhysicalId: "my-function"}, "arn:aws-cn:lambda:cn-north-1:123456789012:function:my-function"},
+		{"us-gov-west-1", CfnResource{Type: "AWS::IAM::Role", PhysicalId: "my-role"}, "arn:aws-us-gov:iam::123456789012:role/my-role"},
+		{"ap-northeast-1", CfnResource{Type: "AWS::SQS::Queue", PhysicalId: "https://sqs.ap-northeast-1.amazonaws.com/123456789012/my-queue"}, "arn:aws:sqs:ap-northeast-1:123456789012:my-queue"},
+		{"ap-northeast-1", CfnResource{Type: "AWS::SSM::Parameter", PhysicalId: "/app/env"}, "arn:aws:ssm:ap-northeast-1:123456789012:parameter/app/env"},
+		{"ap-northeast-1", CfnResource{Type: "AWS::SNS::Topic", PhysicalId: "arn:aws:sns:ap-northeast-1:123456789012:my-topic"}, "arn:aws:sns:ap-northeast-1:123456789012:my-topic"},
+		{"ap-northeast-1", CfnResource{Type: "AWS::Not::Known", PhysicalId: "id"}, ""},
+		{"ap-northeast-1", CfnResource{Type: "AWS::S3::Bucket"}, ""},
+	} {
+		assert.Equal(t, c.arn, resourceArn("123456789012", c.region, c.resource), c.resource)
+	}
+}
+
+func TestLinks_resourceConsoleUrl(t *testing.T) {
+	assert.Equal(t,
+		"https://ap-northeast-1.console.aws.amazon.com/s3/buckets/my-bucket?region=ap-northeast-1",
+		resourceConsoleUrl("123456789012", "ap-northeast-1", CfnResource{Type: "AWS::S3::Bucket", PhysicalId: "my-bucket"}),
+	)
+	assert.Equal(t,
+		"https://ap-northeast-1.console.aws.amazon.com/sqs/v3/home?region=ap-northeast-1#/queues/https%3A%2F%2Fsqs.ap-northeast-1.amazonaws.com%2F123456789012%2Fmy-queue",
+		resourceConsoleUrl("123456789012", "ap-northeast-1", CfnResource{Type: "AWS::SQS::Queue", PhysicalId: "https://sqs.ap-northeast-1.amazonaws.com/123456789012/my-queue"}),
+	)
+	assert.Equal(t,
+		"https://cn-north-1.console.amazonaws.cn/lambda/home?region=cn-north-1#/functions/my-function",
+		resourceConsoleUrl("123456789012", "cn-north-1", CfnResource{Type: "AWS::Lambda::Function", PhysicalId: "my-function"}),
+	)
+	assert.Equal(t, "", resourceConsoleUrl("123456789012", "ap-northeast-1", CfnResource{Type: "AWS::Not::Known", PhysicalId: "id"}))
+
+	assert.Equal(t,
+		"https://ap-northeast-1.console.aws.amazon.com/cloudformation/home?region=ap-northeast-1#/stacks/stackinfo?stackId=arn%3Aaws%3Acloudformation%3Aap-northeast-1%3A123456789012%3Astack%2Fapp%2Fid",
+		stackConsoleUrl("ap-northeast-1", "arn:aws:cloudformation:ap-northeast-1:123456789012:stack/app/id"),
+	)
+	assert.Equal(t, "", stackConsoleUrl("ap-northeast-1", ""))
+}
+
+func TestLinks_toTable(t *testing.T) {
+	resources := []CfnResource{{Type: "AWS::S3::Bucket", PhysicalId: "my-bucket"}}
+	setResourceLinks("123456789012", "ap-northeast-1", resources)
+	views := []*CfnResourcesView{{
+		AccountId: "123456789012", Region: "ap-northeast-1", StackName: "app",
+		StackConsoleUrl: "https://stack", Resources: resources,
+	}}
+
+	table := (&ResourcesCmd{links: true}).toTable(views)
+	assert.Equal(t, []string{"StackName", "StackConsoleUrl", "ResourcePhysicalId", "ResourceArn", "ResourceConsoleUrl"}, table.header[3:8])
+	assert.Equal(t, []interface{}{"app", "https://stack", "my-bucket", "arn:aws:s3:::my-bucket", resources[0].ConsoleUrl}, table.records[0][3:8])
+	assert.True(t, table.links["ResourceConsoleUrl"])
+
+	// columns are not added without the flag
+	table = (&ResourcesCmd{}).toTable(views)
+	assert.Equal(t, "ResourcePhysicalId", table.header[4])
+
+	stackTable := (&StacksCmd{links: true}).toTable([]*CfnStacksView{{StackName: "app", Stack: CfnStack{StackId: "id", ConsoleUrl: "https://stack"}}})
+	assert.Equal(t, []string{"StackId", "StackConsoleUrl"}, stackTable.header[4:6])
+	assert.Equal(t, "https://stack", stackTable.records[0][5])
+}
@@ -2,26 +2,16 @@ package subcommands
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
-	"os"
-	"reflect"
-	"regexp"
-	"sort"
-	"strconv"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/gocarina/gocsv"
 	"github.com/google/subcommands"
-	"github.com/schollz/progressbar/v3"
-	"github.com/xuri/excelize/v2"
 	"golang.org/x/exp/slog"
 
 	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
 )
 
 type CfnOutput struct {
@@ -36,8 +26,11 @@ type CfnOutputsView struct {
 	AccountName string
 	Region      string
 	StackName   string
-	Outputs     []CfnOutput
-	Error       error
+	StackTags   []CfnTag
+	// TemplateHash is the fingerprint of the normalized original template
+	TemplateHash string
+	Outputs      []CfnOutput
+	Error        error
 }
 
 type CfnOutputsCsvView struct {
@@ -49,6 +42,7 @@ type CfnOutputsCsvView struct {
 	OutputValue       string
 	OutputDescription string
 	OutputExportName  string
+	StackTemplateHash string
 	Error             string
 }
 
@@ -58,8 +52,11 @@ type OutputsCmd struct {
 	outFilePath    string
 	format         string
 	verbose        bool
+	tagColumns     []string
 	logger         *slog.Logger
 	config         *config.CfnGlobalViewsConfig
+	templates      *templateCache
+	collected      func(views []*CfnOutputsView)
 }
 
 func (*OutputsCmd) Name() string {
@@ -74,226 +71,30 @@ func (*OutputsCmd) Usage() string {
 func (c *OutputsCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
 	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
-	f.StringVar(&c.format, "f", "csv", "output data format [csv, json, excel] (default is csv)")
+	f.StringVar(&c.format, "f", "csv", formatUsage("csv"))
 	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+	f.Var((*listFlag)(&c.tagColumns), "tag-columns", "comma separated stack tag keys to add as columns to csv and excel outputs e.g. ENV,Owner,CostCenter")
 }
 
 func (c *OutputsCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
-
 	var err error
 
-	if c.configFilePath == "" {
-		fmt.Println("arg '-c path/to/config.yaml' is required")
-		return subcommands.ExitFailure
-	}
-	if c.format != "csv" && c.format != "json" && c.format != "excel" {
-		fmt.Println("allowed values for arg '-f' are [csv, json, excel]")
-		return subcommands.ExitFailure
-	}
-	if c.format == "excel" && c.outFilePath == "" {
-		fmt.Println("if format is excel, must specify output file path arg '-o'")
-		return subcommands.ExitFailure
-	}
-
-	if c.verbose {
-		c.logger = slog.New(slog.NewJSONHandler(os.Stdout))
-	} else {
-		c.logger = slog.New(slog.NewJSONHandler(io.Discard))
-	}
-
-	c.config, err = config.GetConfig(c.configFilePath)
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, nil)
 	if err != nil {
 		fmt.Println(err.Error())
 		return subcommands.ExitFailure
 	}
 
-	globalViews := c.GetGlobalViews()
-
-	if c.format == "csv" {
-		err = c.DumpCsv(globalViews)
-		if err != nil {
-			return subcommands.ExitFailure
-		}
-	}
-	if c.format == "json" {
-		err = c.DumpJson(globalViews)
-		if err != nil {
-			return subcommands.ExitFailure
-		}
-	}
-	if c.format == "excel" {
-		err = c.DumpExcel(globalViews)
-		if err != nil {
-			return subcommands.ExitFailure
-		}
-	}
-
-	return subcommands.ExitSuccess
+	return exitStatus(c.logger, writeGlobalViews(c.format, c.outFilePath, &c.collected, c.GetGlobalViews, c.Report))
 }
 
-func (c *OutputsCmd) DumpExcel(views []*CfnOutputsView) error {
-	csvViews := []CfnOutputsCsvView{}
-
-	for _, view := range views {
-		var errorString string
-		if view.Error == nil {
-			errorString = ""
-		} else {
-			errorString = view.Error.Error()
-		}
-		if len(view.Outputs) == 0 {
-			csvViews = append(csvViews, CfnOutputsCsvView{
-				AccountId:         view.AccountId,
-				AccountName:       view.AccountName,
-				Region:            view.Region,
-				StackName:         view.StackName,
-				OutputName:        "",
-				OutputValue:       "",
-				OutputDescription: "",
-				OutputExportName:  "",
-				Error:             errorString,
-			})
-		}
-		for _, output := range view.Outputs {
-			csvViews = append(csvViews, CfnOutputsCsvView{
-				AccountId:         view.AccountId,
-				AccountName:       view.AccountName,
-				Region:            view.Region,
-				StackName:         view.StackName,
-				OutputName:        output.Name,
-				OutputValue:       output.Value,
-				OutputDescription: output.Description,
-				OutputExportName:  output.ExportName,
-				Error:             errorString,
-			})
-		}
-	}
-
-	var file *excelize.File
-	if _, err := os.Stat(c.outFilePath); err == nil {
-		file, err = excelize.OpenFile(c.outFilePath, excelize.Options{})
-		if err != nil {
-			c.logger.Error(err.Error(), err)
-		}
-	} else {
-		file = excelize.NewFile()
-	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			fmt.Println(err)
-		}
-	}()
-
-	sheetName := c.Name()
-	index, err := file.NewSheet(sheetName)
-	if err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
-	}
-	file.SetActiveSheet(index)
-
-	disable := false
-	numFileds := reflect.TypeOf(csvViews[0]).NumField()
-	startCol := 'B'
-	endCol := startCol + rune(numFileds) - 1
-	startRow := 2
-	endRow := startRow + len(csvViews) - 1
-	startCell := fmt.Sprintf("%s%s", string(startCol), strconv.Itoa(startRow))
-	endCell := fmt.Sprintf("%s%s", string(endCol), strconv.Itoa(endRow))
-
-	// create table
-	err = file.AddTable(sheetName, fmt.Sprintf("%s:%s", startCell, endCell), &excelize.TableOptions{
-		Name:              sheetName,
-		StyleName:         "TableStyleMedium2",
-		ShowFirstColumn:   true,
-		ShowLastColumn:    true,
-		ShowRowStripes:    &disable,
-		ShowColumnStripes: true,
-	})
-	if err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
-	}
-
-	// write table column names
-	curField := 0
-	for curCol := startCol; curCol <= endCol; curCol++ {
-		curCell := fmt.Sprintf("%s%s", string(curCol), strconv.Itoa(startRow))
-		file.SetCellValue(sheetName, curCell, reflect.TypeOf(csvViews[0]).Field(curField).Name)
-		curField++
-	}
-	// write table cell values
-	curRowIndex := 0
-	for curRow := startRow + 1; curRow <= endRow; curRow++ {
-		t := reflect.TypeOf(csvViews[curRowIndex])
-		v := reflect.ValueOf(csvViews[curRowIndex])
-		curColIndex := 0
-		for curCol := startCol; curCol <= endCol; curCol++ {
-			curCell := fmt.Sprintf("%s%s", string(curCol), strconv.Itoa(curRow))
-			curField := t.Field(curColIndex).Name
-			curVal := v.FieldByName(curField).String()
-			file.SetCellValue(sheetName, curCell, curVal)
-			curColIndex++
-		}
-		curRowIndex++
-	}
-
-	// set styles
-	style, err := file.NewStyle(&excelize.Style{
-		Alignment: &excelize.Alignment{
-			ShrinkToFit:     true,
-			Horizontal:      "left",
-			JustifyLastLine: true,
-			WrapText:        true,
-			Vertical:        "center",
-		},
-	})
-	if err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
-	}
-	err = file.SetCellStyle(sheetName, startCell, endCell, style)
-	if err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
-
-	}
-	curField = 0
-	for curCol := startCol; curCol <= endCol; curCol++ {
-
-		maxLength := 0
-		for _, v := range csvViews {
-			if l := len(reflect.ValueOf(v).Field(curField).String()); l > maxLength {
-				maxLength = l
-			}
-		}
-		var colWidth int
-		if maxLength >= 50 {
-			colWidth = 50
-		} else if maxLength <= len(reflect.TypeOf(csvViews[0]).Field(curField).Name) {
-			colWidth = len(reflect.TypeOf(csvViews[0]).Field(curField).Name) + 5
-		} else {
-			colWidth = maxLength
-		}
-		err = file.SetColWidth(sheetName, string(curCol), string(curCol), float64(colWidth))
-		if err != nil {
-			c.logger.Error(err.Error(), err)
-			return err
-		}
-		curField++
-	}
-
-	if err := file.SaveAs(c.outFilePath); err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
-	}
-
-	return nil
-
+func (c *OutputsCmd) Report(views []*CfnOutputsView) *output.Report {
+	return &output.Report{Tables: []*output.Table{c.toTable(views).toOutput(c.Name(), views)}}
 }
 
-func (c *OutputsCmd) DumpCsv(views []*CfnOutputsView) error {
+func (c *OutputsCmd) toTable(views []*CfnOutputsView) *table {
 	csvViews := []CfnOutputsCsvView{}
+	rowTags := [][]CfnTag{}
 
 	for _, view := range views {
 		var errorString string
@@ -312,8 +113,10 @@ func (c *OutputsCmd) DumpCsv(views []*CfnOutputsView) error {
 				OutputValue:       "",
 				OutputDescription: "",
 				OutputExportName:  "",
+				StackTemplateHash: view.TemplateHash,
 				Error:             errorString,
 			})
+			rowTags = append(rowTags, view.StackTags)
 		}
 		for _, output := range view.Outputs {
 			csvViews = append(csvViews, CfnOutputsCsvView{
@@ -325,199 +128,65 @@ func (c *OutputsCmd) DumpCsv(views []*CfnOutputsView) error {
 				OutputValue:       output.Value,
 				OutputDescription: output.Description,
 				OutputExportName:  output.ExportName,
+				StackTemplateHash: view.TemplateHash,
 				Error:             errorString,
 			})
+			rowTags = append(rowTags, view.StackTags)
 		}
 	}
 
-	var writer *os.File
-	var err error
-	if c.outFilePath != "" {
-		writer, err = os.Create(c.outFilePath)
-		if err != nil {
-			return err
-		}
-		defer writer.Close()
-	} else {
-		writer = os.Stdout
-	}
-
-	err = gocsv.Marshal(csvViews, writer)
-	if err != nil {
-		return err
-	}
-
-	return nil
-
-}
-
-func (c *OutputsCmd) DumpJson(views []*CfnOutputsView) error {
-
-	jsonViews, err := json.Marshal(views)
-	if err != nil {
-		return err
-	}
-
-	var writer *os.File
-	if c.outFilePath != "" {
-		writer, err = os.Create(c.outFilePath)
-		if err != nil {
-			return err
-		}
-		defer writer.Close()
-	} else {
-		writer = os.Stdout
-	}
-
-	_, err = writer.Write(jsonViews)
-	if err != nil {
-		return err
-	}
-
-	return nil
-
-}
-
-func (c *OutputsCmd) calcTotalViews() int {
-	total := 0
-	for _, accountConfig := range c.config.AccountConfigs {
-		total += len(accountConfig.Filters.Regions)
-	}
-	return total
+	t := newTable(csvViews)
+	t.insertTagColumns(c.tagColumns, rowTags)
+	return t
 }
 
 func (c *OutputsCmd) GetGlobalViews() []*CfnOutputsView {
-	globalViews := []*CfnOutputsView{}
-
-	totalViews := c.calcTotalViews()
-	progress := 0
-	channel := make(chan []*CfnOutputsView, totalViews)
-
-	var bar *progressbar.ProgressBar
-	if !c.verbose {
-		bar = progressbar.Default(int64(totalViews))
-	}
-	for ai := range c.config.AccountConfigs {
-		for ri := range c.config.AccountConfigs[ai].Filters.Regions {
-
-			go func(ch chan []*CfnOutputsView, ai, ri int) {
-				views := []*CfnOutputsView{}
-				c.logger.Info(
-					"get cfn views", "accountId", c.config.AccountConfigs[ai].Id, "region", c.config.AccountConfigs[ai].Filters.Regions[ri],
-				)
-				// setup cloudformation client
-				var sess *session.Session
-				if c.config.AccountConfigs[ai].Credential.Type == "CLI" {
-					sess = session.Must(session.NewSessionWithOptions(session.Options{
-						Profile: c.config.AccountConfigs[ai].Credential.ProfileName,
-						Config:  *aws.NewConfig().WithRegion(c.config.AccountConfigs[ai].Filters.Regions[ri]),
-					}))
-				} else {
-					sess = session.Must(session.NewSessionWithOptions(session.Options{
-						Config: *aws.NewConfig().WithRegion(c.config.AccountConfigs[ai].Filters.Regions[ri]),
-					}))
-				}
-
-				cfn := cloudformation.New(sess)
-
-				// describe all stacks at the account and region and filter them
-				var matchedStacks []cloudformation.Stack
-				for {
-					describeStacksOutpus, err := cfn.DescribeStacks(&cloudformation.DescribeStacksInput{})
-					if err != nil {
-						views = append(views, &CfnOutputsView{
-							AccountId:   c.config.AccountConfigs[ai].Id,
-							AccountName: c.config.AccountConfigs[ai].Name,
-							Region:      c.config.AccountConfigs[ai].Filters.Regions[ri],
-							Error:       err,
-						})
-
-						break
-					}
-					for _, stack := range describeStacksOutpus.Stacks {
-
-						matched, _ := regexp.MatchString(c.config.AccountConfigs[ai].Filters.StackNameRegex, *stack.StackName)
-						if matched && c.hasAllTags(stack.Tags, c.config.AccountConfigs[ai].Filters.StackTags) {
-							c.logger.Info(fmt.Sprintf("matched cfn stack: %s", *stack.StackName), "accountId", c.config.AccountConfigs[ai].Id, "region", c.config.AccountConfigs[ai].Filters.Regions[ri])
-							matchedStacks = append(matchedStacks, *stack)
-						}
-					}
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, c.collected)
 
-					if describeStacksOutpus.NextToken == nil {
-						break
-					}
-				}
-
-				// describe matched stacks' output definitions
-				for _, matchedStack := range matchedStacks {
-					var outputs []CfnOutput
-					for _, output := range matchedStack.Outputs {
-						description := ""
-						exportName := ""
-						if d := output.Description; d != nil {
-							description = *d
-						}
-						if d := output.ExportName; d != nil {
-							exportName = *d
-						}
-						outputs = append(outputs, CfnOutput{
-							Name:        *output.OutputKey,
-							Value:       *output.OutputValue,
-							Description: description,
-							ExportName:  exportName,
-						})
-					}
-					views = append(views, &CfnOutputsView{
-						AccountId:   c.config.AccountConfigs[ai].Id,
-						AccountName: c.config.AccountConfigs[ai].Name,
-						Region:      c.config.AccountConfigs[ai].Filters.Regions[ri],
-						StackName:   *matchedStack.StackName,
-						Outputs:     outputs,
-						Error:       nil,
-					})
-				}
-
-				ch <- views
-
-				if !c.verbose {
-					bar.Add(1)
-				}
-				progress += 1
-				if progress == totalViews {
-					close(ch)
-				}
-			}(channel, ai, ri)
-		}
-	}
-
-	for views := range channel {
-		globalViews = append(globalViews, views...)
-	}
-
-	sort.Slice(globalViews, func(i, j int) bool { return globalViews[i].AccountId < globalViews[j].AccountId })
-	sort.Slice(globalViews, func(i, j int) bool { return globalViews[i].Region < globalViews[j].Region })
-	sort.Slice(globalViews, func(i, j int) bool { return globalViews[i].StackName < globalViews[j].StackName })
+	sortViews(globalViews, func(view *CfnOutputsView) []string {
+		return []string{view.AccountId, view.Region, view.StackName}
+	})
 
 	return globalViews
-
 }
 
-func (c *OutputsCmd) hasAllTags(stackTags []*cloudformation.Tag, filterTags []config.Tag) bool {
-	hasAllTags := []bool{}
+func (c *OutputsCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnOutputsView {
+	views := []*CfnOutputsView{}
+	c.logger.Info("get cfn views", "accountId", accountConfig.Id, "region", region)
 
-	if len(filterTags) == 0 {
-		return true
-	}
-	for _, filterTag := range filterTags {
-		for _, stackTag := range stackTags {
-			if filterTag.Key == *stackTag.Key && filterTag.Value == *stackTag.Value {
-				hasAllTags = append(hasAllTags, true)
-			}
+	cfn := cloudformation.New(newSession(accountConfig, region))
+
+	matchedStacks, err := describeMatchedStacks(cfn, accountConfig.Filters)
+	if err != nil {
+		return append(views, &CfnOutputsView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Error:       err,
+		})
+	}
+
+	for _, matchedStack := range matchedStacks {
+		c.logger.Info(fmt.Sprintf("matched cfn stack: %s", *matchedStack.StackName), "accountId", accountConfig.Id, "region", region)
+		var outputs []CfnOutput
+		for _, output := range matchedStack.Outputs {
+			outputs = append(outputs, CfnOutput{
+				Name:        aws.StringValue(output.OutputKey),
+				Value:       aws.StringValue(output.OutputValue),
+				Description: aws.StringValue(output.Description),
+				ExportName:  aws.StringValue(output.ExportName),
+			})
 		}
+		views = append(views, &CfnOutputsView{
+			AccountId:    accountConfig.Id,
+			AccountName:  accountConfig.Name,
+			Region:       region,
+			StackName:    *matchedStack.StackName,
+			StackTags:    newCfnTags(matchedStack.Tags),
+			Outputs:      outputs,
+			TemplateHash: optionalTemplateFingerprint(c.templates.getOptionalTemplateBody(cfn, *matchedStack.StackId, c.logger)),
+		})
 	}
-	if len(hasAllTags) == len(filterTags) {
-		return true
-	} else {
-		return false
-	}
+
+	return views
 }
@@ -1,7 +1,6 @@
 package subcommands
 
 import (
-	"os"
 	"testing"
 
 	"github.com/gookit/config/v2"
@@ -45,25 +44,7 @@ func TestOutputs_valid(t *testing.T) {
 }
 
 func TestOutputs_invalid(t *testing.T) {
-	defer config.ClearAll()
-	defer func() { os.Remove(TMP_CONFIG_PATH) }()
-
-	tmpConfigYaml := `
-RootConfig:
-  Credential:
-    Type: "CLI"
-    ProfileName: not-exist-profile
-  Filters:
-    Regions:
-      - "not-exist-region"
-AccountConfigs:
-  - Name: main-account
-    Id: 123456789012
-`
-	writeTmpYaml(tmpConfigYaml)
-
-	c, err := cfnConfig.GetConfig(TMP_CONFIG_PATH)
-	assert.Nil(t, err)
+	c := getInvalidConfig(t, "")
 
 	cmd := OutputsCmd{
 		config: c,
@@ -2,34 +2,48 @@ package subcommands
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
-	"os"
-	"reflect"
-	"regexp"
-	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/gocarina/gocsv"
 	"github.com/google/subcommands"
-	"github.com/schollz/progressbar/v3"
-	"github.com/xuri/excelize/v2"
 	"golang.org/x/exp/slog"
 
 	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+const (
+	PARAMETER_PROVENANCE_DEFAULT      = "Default"
+	PARAMETER_PROVENANCE_OVERRIDE     = "Override"
+	PARAMETER_PROVENANCE_SSM_RESOLVED = "SSMResolved"
+	PARAMETER_PROVENANCE_NO_ECHO      = "NoEchoMasked"
 )
 
 type CfnParameter struct {
-	Name         string
+	Name string
+	// Group and Label are from ParameterGroups and ParameterLabels of AWS::CloudFormation::Interface metadata
+	Group        string
+	Label        string
 	Type         string
 	Description  string
 	DefaultValue string
 	ActualValue  string
+	// ResolvedValue is the value resolved from ssm parameter store for ssm parameter types
+	ResolvedValue string
+	// Provenance is where the actual value comes from, one of PARAMETER_PROVENANCE_*
+	Provenance  string
+	NoEcho      bool
+	Constraints CfnParameterConstraints
+	// ConstraintViolation describes how the actual value violates the constraints of the current template
+	ConstraintViolation string
+	// groupOrder is the order of the parameter in the console, grouped parameters first
+	groupOrder int
+	// hasDefault is whether the template declares a default value, which can be an empty string
+	hasDefault bool
 }
 
 type CfnParametersView struct {
@@ -37,21 +51,33 @@ type CfnParametersView struct {
 	AccountName string
 	Region      string
 	StackName   string
-	Parameters  []CfnParameter
-	Error       error
+	StackTags   []CfnTag
+	// TemplateHash is the fingerprint of the normalized original template
+	TemplateHash string
+	Parameters   []CfnParameter
+	Error        error
 }
 
 type CfnParametersCsvView struct {
-	AccountId             string
-	AccountName           string
-	Region                string
-	StackName             string
-	ParameterName         string
-	ParameterType         string
-	ParameterDescription  string
-	ParameterDefaultValue string
-	ParameterActualValue  string
-	Error                 string
+	AccountId                    string
+	AccountName                  string
+	Region                       string
+	StackName                    string
+	ParameterName                string
+	ParameterLabel               string
+	ParameterGroup               string
+	ParameterType                string
+	ParameterDescription         string
+	ParameterDefaultValue        string
+	ParameterActualValue         string
+	ParameterResolvedValue       string
+	ParameterProvenance          string
+	ParameterNoEcho              string
+	ParameterAllowedValues       string
+	ParameterConstraints         string
+	ParameterConstraintViolation string
+	StackTemplateHash            string
+	Error                        string
 }
 
 type ParametersCmd struct {
@@ -60,8 +86,17 @@ type ParametersCmd struct {
 	outFilePath    string
 	format         string
 	verbose        bool
+	tagColumns     []string
+	pivot          bool
+	consistency    bool
+	allowDiffer    []string
+	nonDefault     bool
+	violationsOnly bool
+	namePatterns   []string
 	logger         *slog.Logger
 	config         *config.CfnGlobalViewsConfig
+	templates      *templateCache
+	collected      func(views []*CfnParametersView)
 }
 
 func (*ParametersCmd) Name() string {
@@ -71,131 +106,45 @@ func (*ParametersCmd) Synopsis() string {
 	return "list cfn parameters"
 }
 func (*ParametersCmd) Usage() string {
-	return "parameters -c path/to/config.yaml"
+	return "parameters -c path/to/config.yaml [-pivot | -consistency [-allow-differ Env,StackType]] [-name-patterns ^app-.*$,^network-.*$]"
 }
 func (c *ParametersCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
 	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
-	f.StringVar(&c.format, "f", "csv", "output data format [csv, json, excel] (default is csv)")
+	f.StringVar(&c.format, "f", "csv", formatUsage("csv"))
 	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+	f.Var((*listFlag)(&c.tagColumns), "tag-columns", "comma separated stack tag keys to add as columns to csv and excel outputs e.g. ENV,Owner,CostCenter")
+	f.BoolVar(&c.nonDefault, "non-default", false, "if set, only list parameters whose actual values differ from their default values. NoEcho parameters are always listed")
+	f.BoolVar(&c.violationsOnly, "violations-only", false, "if set, only list parameters whose actual values violate constraints of the current templates")
+	f.BoolVar(&c.pivot, "pivot", false, "if set, output parameters pivoted into a row per stack group and parameter and a column per account and region. excel output has both sheets")
+	f.BoolVar(&c.consistency, "consistency", false, "if set, output parameters whose actual values differ between stacks in the same group. excel output has both sheets")
+	f.Var((*listFlag)(&c.allowDiffer), "allow-differ", "comma separated glob patterns of parameter names expected to differ between stacks, excluded from the consistency report e.g. Env,StackType")
+	f.Var((*listFlag)(&c.namePatterns), "name-patterns", "comma separated regex patterns of stack names to group stacks by. stacks not matching any patterns are grouped by template hashes")
 }
 
 func (c *ParametersCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
-
 	var err error
 
-	if c.configFilePath == "" {
-		fmt.Println("arg '-c path/to/config.yaml' is required")
-		return subcommands.ExitFailure
-	}
-	if c.format != "csv" && c.format != "json" && c.format != "excel" {
-		fmt.Println("allowed values for arg '-f' are [csv, json, excel]")
-		return subcommands.ExitFailure
-	}
-	if c.format == "excel" && c.outFilePath == "" {
-		fmt.Println("if format is excel, must specify output file path arg '-o'")
-		return subcommands.ExitFailure
-	}
-
-	if c.verbose {
-		c.logger = slog.New(slog.NewJSONHandler(os.Stdout))
-	} else {
-		c.logger = slog.New(slog.NewJSONHandler(io.Discard))
-	}
-
-	c.config, err = config.GetConfig(c.configFilePath)
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, c.validate)
 	if err != nil {
 		fmt.Println(err.Error())
 		return subcommands.ExitFailure
 	}
 
-	globalViews := c.GetGlobalViews()
-
-	if c.format == "csv" {
-		err = c.DumpCsv(globalViews)
-		if err != nil {
-			return subcommands.ExitFailure
-		}
-	}
-	if c.format == "json" {
-		err = c.DumpJson(globalViews)
-		if err != nil {
-			return subcommands.ExitFailure
-		}
-	}
-	if c.format == "excel" {
-		err = c.DumpExcel(globalViews)
-		if err != nil {
-			return subcommands.ExitFailure
-		}
-	}
-
-	return subcommands.ExitSuccess
+	return exitStatus(c.logger, writeGlobalViews(c.format, c.outFilePath, &c.collected, c.GetGlobalViews, c.Report))
 }
 
-func (c *ParametersCmd) DumpCsv(views []*CfnParametersView) error {
-	csvViews := []CfnParametersCsvView{}
-
-	for _, view := range views {
-		var errorString string
-		if view.Error == nil {
-			errorString = ""
-		} else {
-			errorString = view.Error.Error()
-		}
-		if len(view.Parameters) == 0 {
-			csvViews = append(csvViews, CfnParametersCsvView{
-				AccountId:             view.AccountId,
-				AccountName:           view.AccountName,
-				Region:                view.Region,
-				StackName:             view.StackName,
-				ParameterName:         "",
-				ParameterType:         "",
-				ParameterDescription:  "",
-				ParameterDefaultValue: "",
-				ParameterActualValue:  "",
-				Error:                 errorString,
-			})
-		}
-		for _, parameter := range view.Parameters {
-			csvViews = append(csvViews, CfnParametersCsvView{
-				AccountId:             view.AccountId,
-				AccountName:           view.AccountName,
-				Region:                view.Region,
-				StackName:             view.StackName,
-				ParameterName:         parameter.Name,
-				ParameterType:         parameter.Type,
-				ParameterDescription:  parameter.Description,
-				ParameterDefaultValue: parameter.DefaultValue,
-				ParameterActualValue:  parameter.ActualValue,
-				Error:                 errorString,
-			})
-		}
-	}
-
-	var writer *os.File
-	var err error
-	if c.outFilePath != "" {
-		writer, err = os.Create(c.outFilePath)
-		if err != nil {
-			return err
-		}
-		defer writer.Close()
-	} else {
-		writer = os.Stdout
+// validate checks '-pivot' and '-consistency' args.
+func (c *ParametersCmd) validate() error {
+	if c.format != "excel" && c.pivot && c.consistency {
+		return fmt.Errorf("args '-pivot' and '-consistency' can be set together only if format is excel")
 	}
-
-	err = gocsv.Marshal(csvViews, writer)
-	if err != nil {
-		return err
-	}
-
 	return nil
-
 }
 
-func (c *ParametersCmd) DumpExcel(views []*CfnParametersView) error {
+func (c *ParametersCmd) toTable(views []*CfnParametersView) *table {
 	csvViews := []CfnParametersCsvView{}
+	rowTags := [][]CfnTag{}
 
 	for _, view := range views {
 		var errorString string
@@ -206,348 +155,217 @@ func (c *ParametersCmd) DumpExcel(views []*CfnParametersView) error {
 		}
 		if len(view.Parameters) == 0 {
 			csvViews = append(csvViews, CfnParametersCsvView{
-				AccountId:             view.AccountId,
-				AccountName:           view.AccountName,
-				Region:                view.Region,
-				StackName:             view.StackName,
-				ParameterName:         "",
-				ParameterType:         "",
-				ParameterDescription:  "",
-				ParameterDefaultValue: "",
-				ParameterActualValue:  "",
-				Error:                 errorString,
+				AccountId:                    view.AccountId,
+				AccountName:                  view.AccountName,
+				Region:                       view.Region,
+				StackName:                    view.StackName,
+				ParameterName:                "",
+				ParameterLabel:               "",
+				ParameterGroup:               "",
+				ParameterType:                "",
+				ParameterDescription:         "",
+				ParameterDefaultValue:        "",
+				ParameterActualValue:         "",
+				ParameterResolvedValue:       "",
+				ParameterProvenance:          "",
+				ParameterNoEcho:              "",
+				ParameterAllowedValues:       "",
+				ParameterConstraints:         "",
+				ParameterConstraintViolation: "",
+				StackTemplateHash:            view.TemplateHash,
+				Error:                        errorString,
 			})
+			rowTags = append(rowTags, view.StackTags)
 		}
 		for _, parameter := range view.Parameters {
 			csvViews = append(csvViews, CfnParametersCsvView{
-				AccountId:             view.AccountId,
-				AccountName:           view.AccountName,
-				Region:                view.Region,
-				StackName:             view.StackName,
-				ParameterName:         parameter.Name,
-				ParameterType:         parameter.Type,
-				ParameterDescription:  parameter.Description,
-				ParameterDefaultValue: parameter.DefaultValue,
-				ParameterActualValue:  parameter.ActualValue,
-				Error:                 errorString,
+				AccountId:                    view.AccountId,
+				AccountName:                  view.AccountName,
+				Region:                       view.Region,
+				StackName:                    view.StackName,
+				ParameterName:                parameter.Name,
+				ParameterLabel:               parameter.Label,
+				ParameterGroup:               parameter.Group,
+				ParameterType:                parameter.Type,
+				ParameterDescription:         parameter.Description,
+				ParameterDefaultValue:        parameter.DefaultValue,
+				ParameterActualValue:         parameter.ActualValue,
+				ParameterResolvedValue:       parameter.ResolvedValue,
+				ParameterProvenance:          parameter.Provenance,
+				ParameterNoEcho:              strconv.FormatBool(parameter.NoEcho),
+				ParameterAllowedValues:       strings.Join(parameter.Constraints.AllowedValues, ","),
+				ParameterConstraints:         parameter.Constraints.String(),
+				ParameterConstraintViolation: parameter.ConstraintViolation,
+				StackTemplateHash:            view.TemplateHash,
+				Error:                        errorString,
 			})
+			rowTags = append(rowTags, view.StackTags)
 		}
 	}
 
-	var file *excelize.File
-	if _, err := os.Stat(c.outFilePath); err == nil {
-		file, err = excelize.OpenFile(c.outFilePath, excelize.Options{})
-		if err != nil {
-			c.logger.Error(err.Error(), err)
-		}
-	} else {
-		file = excelize.NewFile()
-	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			fmt.Println(err)
-		}
-	}()
+	t := newTable(csvViews)
+	t.insertTagColumns(c.tagColumns, rowTags)
+	return t
+}
 
-	sheetName := c.Name()
-	_ = file.DeleteSheet(sheetName)
-	index, err := file.NewSheet(sheetName)
-	if err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
-	}
-	file.SetActiveSheet(index)
+// Report has parameters, and the pivot and the consistency tables if requested.
+// the pivot or the consistency table is the main table if requested.
+func (c *ParametersCmd) Report(views []*CfnParametersView) *output.Report {
+	listedViews := c.listedViews(views)
+	report := &output.Report{Tables: []*output.Table{
+		c.toTable(sortParametersByGroup(listedViews)).toOutput(c.Name(), listedViews),
+	}}
+	if c.pivot {
+		pivot := c.GetPivot(views)
+		report.Main = len(report.Tables)
+		report.Aggregated = true
+		report.Tables = append(report.Tables, pivot.toTable().toOutput(c.Name()+"-pivot", pivot))
+	}
+	if c.consistency {
+		inconsistencies := c.GetInconsistencies(views)
+		report.Main = len(report.Tables)
+		report.Aggregated = true
+		report.Tables = append(report.Tables, newTable(c.toConsistencyCsvViews(inconsistencies)).toOutput(c.Name()+"-consistency", inconsistencies))
+	}
+	return report
+}
 
-	disable := false
-	numFileds := reflect.TypeOf(csvViews[0]).NumField()
-	startCol := 'B'
-	endCol := startCol + rune(numFileds) - 1
-	startRow := 2
-	endRow := startRow + len(csvViews) - 1
-	startCell := fmt.Sprintf("%s%s", string(startCol), strconv.Itoa(startRow))
-	endCell := fmt.Sprintf("%s%s", string(endCol), strconv.Itoa(endRow))
+func (c *ParametersCmd) GetGlobalViews() []*CfnParametersView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, c.collected)
 
-	// create table
-	err = file.AddTable(sheetName, fmt.Sprintf("%s:%s", startCell, endCell), &excelize.TableOptions{
-		Name:              sheetName,
-		StyleName:         "TableStyleMedium2",
-		ShowFirstColumn:   true,
-		ShowLastColumn:    true,
-		ShowRowStripes:    &disable,
-		ShowColumnStripes: true,
+	sortViews(globalViews, func(view *CfnParametersView) []string {
+		return []string{view.AccountId, view.Region, view.StackName}
 	})
-	if err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
-	}
 
-	// write table column names
-	curField := 0
-	for curCol := startCol; curCol <= endCol; curCol++ {
-		curCell := fmt.Sprintf("%s%s", string(curCol), strconv.Itoa(startRow))
-		file.SetCellValue(sheetName, curCell, reflect.TypeOf(csvViews[0]).Field(curField).Name)
-		curField++
-	}
-	// write table cell values
-	curRowIndex := 0
-	for curRow := startRow + 1; curRow <= endRow; curRow++ {
-		t := reflect.TypeOf(csvViews[curRowIndex])
-		v := reflect.ValueOf(csvViews[curRowIndex])
-		curColIndex := 0
-		for curCol := startCol; curCol <= endCol; curCol++ {
-			curCell := fmt.Sprintf("%s%s", string(curCol), strconv.Itoa(curRow))
-			curField := t.Field(curColIndex).Name
-			curVal := v.FieldByName(curField).String()
-			file.SetCellValue(sheetName, curCell, curVal)
-			curColIndex++
-		}
-		curRowIndex++
-	}
+	return globalViews
+}
 
-	// set styles
-	style, err := file.NewStyle(&excelize.Style{
-		Alignment: &excelize.Alignment{
-			ShrinkToFit:     true,
-			Horizontal:      "left",
-			JustifyLastLine: true,
-			WrapText:        true,
-			Vertical:        "center",
-		},
-	})
-	if err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
-	}
-	err = file.SetCellStyle(sheetName, startCell, endCell, style)
-	if err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
+func (c *ParametersCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnParametersView {
+	views := []*CfnParametersView{}
+	c.logger.Info("get cfn views", "accountId", accountConfig.Id, "region", region)
 
-	}
-	curField = 0
-	for curCol := startCol; curCol <= endCol; curCol++ {
+	sess := newSession(accountConfig, region)
+	cfn := cloudformation.New(sess)
 
-		maxLength := 0
-		for _, v := range csvViews {
-			if l := len(reflect.ValueOf(v).Field(curField).String()); l > maxLength {
-				maxLength = l
-			}
-		}
-		var colWidth int
-		if maxLength >= 50 {
-			colWidth = 50
-		} else if maxLength <= len(reflect.TypeOf(csvViews[0]).Field(curField).Name) {
-			colWidth = len(reflect.TypeOf(csvViews[0]).Field(curField).Name) + 5
-		} else {
-			colWidth = maxLength
-		}
-		err = file.SetColWidth(sheetName, string(curCol), string(curCol), float64(colWidth))
+	matchedStacks, err := describeMatchedStacks(cfn, accountConfig.Filters)
+	if err != nil {
+		return append(views, &CfnParametersView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Error:       err,
+		})
+	}
+
+	// describe matched stacks' parameters definitions
+	for _, matchedStack := range matchedStacks {
+		c.logger.Info(fmt.Sprintf("matched cfn stack: %s", *matchedStack.StackName), "accountId", accountConfig.Id, "region", region)
+		templateSummary, err := cfn.GetTemplateSummary(&cloudformation.GetTemplateSummaryInput{
+			StackName: matchedStack.StackId,
+		})
 		if err != nil {
-			c.logger.Error(err.Error(), err)
-			return err
+			views = append(views, &CfnParametersView{
+				AccountId:   accountConfig.Id,
+				AccountName: accountConfig.Name,
+				Region:      region,
+				StackName:   *matchedStack.StackName,
+				StackTags:   newCfnTags(matchedStack.Tags),
+				Error:       err,
+			})
+			continue
 		}
-		curField++
-	}
-
-	if err := file.SaveAs(c.outFilePath); err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
-	}
-
-	return nil
-
+		templateBody := c.templates.getOptionalTemplateBody(cfn, *matchedStack.StackId, c.logger)
+		templateConstraints := parseTemplateParameters(templateBody)
+		parameterInterface := parseParameterInterface(aws.StringValue(templateSummary.Metadata))
+		var parameters []CfnParameter
+		for _, parameter := range templateSummary.Parameters {
+			cfnParameter := newCfnParameter(parameter, matchedStack.Parameters)
+			constraints := templateConstraints[cfnParameter.Name]
+			if len(cfnParameter.Constraints.AllowedValues) != 0 {
+				constraints.AllowedValues = cfnParameter.Constraints.AllowedValues
+			}
+			cfnParameter.Constraints = constraints
+			cfnParameter.ConstraintViolation = validateParameterValue(cfnParameter)
+			parameterInterface.apply(&cfnParameter)
+			parameters = append(parameters, cfnParameter)
+		}
+		views = append(views, &CfnParametersView{
+			AccountId:    accountConfig.Id,
+			AccountName:  accountConfig.Name,
+			Region:       region,
+			StackName:    *matchedStack.StackName,
+			StackTags:    newCfnTags(matchedStack.Tags),
+			Parameters:   parameters,
+			TemplateHash: optionalTemplateFingerprint(templateBody),
+		})
+	}
+
+	return views
 }
 
-func (c *ParametersCmd) DumpJson(views []*CfnParametersView) error {
-
-	jsonViews, err := json.Marshal(views)
-	if err != nil {
-		return err
+// listedViews returns the views with only the parameters passing -non-default and -violations-only.
+// the pivot and the consistency tables are made from all parameters, not from the listed ones.
+func (c *ParametersCmd) listedViews(views []*CfnParametersView) []*CfnParametersView {
+	if !c.nonDefault && !c.violationsOnly {
+		return views
 	}
-
-	var writer *os.File
-	if c.outFilePath != "" {
-		writer, err = os.Create(c.outFilePath)
-		if err != nil {
-			return err
+	listedViews := []*CfnParametersView{}
+	for _, view := range views {
+		listedView := *view
+		listedView.Parameters = nil
+		for _, parameter := range view.Parameters {
+			if c.listed(parameter) {
+				listedView.Parameters = append(listedView.Parameters, parameter)
+			}
 		}
-		defer writer.Close()
-	} else {
-		writer = os.Stdout
-	}
-
-	_, err = writer.Write(jsonViews)
-	if err != nil {
-		return err
+		listedViews = append(listedViews, &listedView)
 	}
-
-	return nil
-
+	return listedViews
 }
 
-func (c *ParametersCmd) calcTotalViews() int {
-	total := 0
-	for _, accountConfig := range c.config.AccountConfigs {
-		total += len(accountConfig.Filters.Regions)
+// listed returns whether the parameter passes -non-default and -violations-only.
+// with -non-default, the actual value is compared with the default value whatever the provenance is,
+// so ssm parameters still at their default paths are dropped.
+// NoEcho parameters are kept, since their actual values are masked.
+func (c *ParametersCmd) listed(parameter CfnParameter) bool {
+	if c.nonDefault && !parameter.NoEcho && parameter.hasDefault && parameter.ActualValue == parameter.DefaultValue {
+		return false
 	}
-	return total
-}
-
-func (c *ParametersCmd) GetGlobalViews() []*CfnParametersView {
-	globalViews := []*CfnParametersView{}
-
-	totalViews := c.calcTotalViews()
-	progress := 0
-	channel := make(chan []*CfnParametersView, totalViews)
-
-	var bar *progressbar.ProgressBar
-	if !c.verbose {
-		bar = progressbar.Default(int64(totalViews))
+	if c.violationsOnly && parameter.ConstraintViolation == "" {
+		return false
 	}
-	for ai := range c.config.AccountConfigs {
-		for ri := range c.config.AccountConfigs[ai].Filters.Regions {
-
-			go func(ch chan []*CfnParametersView, ai, ri int) {
-				views := []*CfnParametersView{}
-				c.logger.Info(
-					"get cfn views", "accountId", c.config.AccountConfigs[ai].Id, "region", c.config.AccountConfigs[ai].Filters.Regions[ri],
-				)
-				// setup cloudformation client
-				var sess *session.Session
-				if c.config.AccountConfigs[ai].Credential.Type == "CLI" {
-					sess = session.Must(session.NewSessionWithOptions(session.Options{
-						Profile: c.config.AccountConfigs[ai].Credential.ProfileName,
-						Config:  *aws.NewConfig().WithRegion(c.config.AccountConfigs[ai].Filters.Regions[ri]),
-					}))
-				} else {
-					sess = session.Must(session.NewSessionWithOptions(session.Options{
-						Config: *aws.NewConfig().WithRegion(c.config.AccountConfigs[ai].Filters.Regions[ri]),
-					}))
-				}
-
-				cfn := cloudformation.New(sess)
-
-				// describe all stacks at the account and region and filter them
-				var matchedStacks []cloudformation.Stack
-				for {
-					describeStacksOutpus, err := cfn.DescribeStacks(&cloudformation.DescribeStacksInput{})
-					if err != nil {
-						views = append(views, &CfnParametersView{
-							AccountId:   c.config.AccountConfigs[ai].Id,
-							AccountName: c.config.AccountConfigs[ai].Name,
-							Region:      c.config.AccountConfigs[ai].Filters.Regions[ri],
-							Error:       err,
-						})
-
-						break
-					}
-					for _, stack := range describeStacksOutpus.Stacks {
-
-						matched, _ := regexp.MatchString(c.config.AccountConfigs[ai].Filters.StackNameRegex, *stack.StackName)
-						if matched && c.hasAllTags(stack.Tags, c.config.AccountConfigs[ai].Filters.StackTags) {
-							c.logger.Info(fmt.Sprintf("matched cfn stack: %s", *stack.StackName), "accountId", c.config.AccountConfigs[ai].Id, "region", c.config.AccountConfigs[ai].Filters.Regions[ri])
-							matchedStacks = append(matchedStacks, *stack)
-						}
-					}
-
-					if describeStacksOutpus.NextToken == nil {
-						break
-					}
-				}
-
-				// describe matched stacks' parameters definitions
-				for _, matchedStack := range matchedStacks {
-					templateSummary, err := cfn.GetTemplateSummary(&cloudformation.GetTemplateSummaryInput{
-						StackName: matchedStack.StackName,
-					})
-					if err != nil {
-						views = append(views, &CfnParametersView{
-							AccountId:   c.config.AccountConfigs[ai].Id,
-							AccountName: c.config.AccountConfigs[ai].Name,
-							Region:      c.config.AccountConfigs[ai].Filters.Regions[ri],
-							StackName:   *matchedStack.StackName,
-							Error:       err,
-						})
-						break
-					}
-					var parameters []CfnParameter
-					for _, parameter := range templateSummary.Parameters {
-						description := ""
-						defaultValue := ""
-						if parameter.Description != nil {
-							description = *parameter.Description
-						}
-						if parameter.DefaultValue != nil {
-							defaultValue = *parameter.DefaultValue
-						}
-						parameters = append(parameters, CfnParameter{
-							Name:         *parameter.ParameterKey,
-							Type:         *parameter.ParameterType,
-							Description:  description,
-							DefaultValue: defaultValue,
-							ActualValue:  c.getActulaParameterValue(parameter, matchedStack.Parameters),
-						})
-					}
-					views = append(views, &CfnParametersView{
-						AccountId:   c.config.AccountConfigs[ai].Id,
-						AccountName: c.config.AccountConfigs[ai].Name,
-						Region:      c.config.AccountConfigs[ai].Filters.Regions[ri],
-						StackName:   *matchedStack.StackName,
-						Parameters:  parameters,
-						Error:       nil,
-					})
-				}
-
-				ch <- views
+	return true
+}
 
-				if !c.verbose {
-					bar.Add(1)
-				}
-				progress += 1
-				if progress == totalViews {
-					close(ch)
-				}
-			}(channel, ai, ri)
-		}
+// newCfnParameter returns the parameter declared in the template with its actual value in the stack.
+func newCfnParameter(parameterDeclaration *cloudformation.ParameterDeclaration, parameters []*cloudformation.Parameter) CfnParameter {
+	cfnParameter := CfnParameter{
+		Name:         aws.StringValue(parameterDeclaration.ParameterKey),
+		Type:         aws.StringValue(parameterDeclaration.ParameterType),
+		Description:  aws.StringValue(parameterDeclaration.Description),
+		DefaultValue: aws.StringValue(parameterDeclaration.DefaultValue),
+		NoEcho:       aws.BoolValue(parameterDeclaration.NoEcho),
+		hasDefault:   parameterDeclaration.DefaultValue != nil,
 	}
-
-	for views := range channel {
-		globalViews = append(globalViews, views...)
+	if constraints := parameterDeclaration.ParameterConstraints; constraints != nil {
+		cfnParameter.Constraints.AllowedValues = aws.StringValueSlice(constraints.AllowedValues)
 	}
-
-	sort.Slice(globalViews, func(i, j int) bool { return globalViews[i].AccountId < globalViews[j].AccountId })
-	sort.Slice(globalViews, func(i, j int) bool { return globalViews[i].Region < globalViews[j].Region })
-	sort.Slice(globalViews, func(i, j int) bool { return globalViews[i].StackName < globalViews[j].StackName })
-
-	return globalViews
-
-}
-
-func (c *ParametersCmd) getActulaParameterValue(parameterDeclaration *cloudformation.ParameterDeclaration, parameters []*cloudformation.Parameter) string {
 	for _, parameter := range parameters {
 		if *parameterDeclaration.ParameterKey == *parameter.ParameterKey {
-			return *parameter.ParameterValue
+			cfnParameter.ActualValue = aws.StringValue(parameter.ParameterValue)
+			cfnParameter.ResolvedValue = aws.StringValue(parameter.ResolvedValue)
 		}
 	}
-	return ""
-}
 
-func (c *ParametersCmd) hasAllTags(stackTags []*cloudformation.Tag, filterTags []config.Tag) bool {
-	hasAllTags := []bool{}
-
-	if len(filterTags) == 0 {
-		return true
-	}
-	for _, filterTag := range filterTags {
-		for _, stackTag := range stackTags {
-			if filterTag.Key == *stackTag.Key && filterTag.Value == *stackTag.Value {
-				hasAllTags = append(hasAllTags, true)
-			}
-		}
-	}
-	if len(hasAllTags) == len(filterTags) {
-		return true
-	} else {
-		return false
+	switch {
+	case cfnParameter.NoEcho:
+		cfnParameter.Provenance = PARAMETER_PROVENANCE_NO_ECHO
+	case cfnParameter.ResolvedValue != "":
+		cfnParameter.Provenance = PARAMETER_PROVENANCE_SSM_RESOLVED
+	case cfnParameter.hasDefault && cfnParameter.ActualValue == cfnParameter.DefaultValue:
+		cfnParameter.Provenance = PARAMETER_PROVENANCE_DEFAULT
+	default:
+		cfnParameter.Provenance = PARAMETER_PROVENANCE_OVERRIDE
 	}
+	return cfnParameter
 }
@@ -0,0 +1,97 @@
+package subcommands
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type CfnParameterValueStacks struct {
+	Value  string
+	Stacks []string
+}
+
+// CfnParameterInconsistency is a parameter whose actual values differ between stacks in the same group.
+type CfnParameterInconsistency struct {
+	Group         string
+	ParameterName string
+	Values        []CfnParameterValueStacks
+}
+
+type CfnParameterConsistencyCsvView struct {
+	Group          string
+	ParameterName  string
+	ParameterValue string
+	StackCount     string
+	Stacks         string
+}
+
+// GetInconsistencies groups stacks by templates or name patterns, and returns parameters whose actual values
+// differ between stacks in each group, except parameters allowed to differ.
+// values are ordered by the number of stacks having them in descending order.
+func (c *ParametersCmd) GetInconsistencies(views []*CfnParametersView) []CfnParameterInconsistency {
+	type key struct{ group, name string }
+	keys := []key{}
+	values := map[key]map[string][]string{}
+	for _, view := range views {
+		if view.StackName == "" {
+			continue
+		}
+		group := c.stackGroupOf(view)
+		stack := fmt.Sprintf("%s/%s/%s", view.AccountId, view.Region, view.StackName)
+		for _, parameter := range view.Parameters {
+			if len(c.allowDiffer) != 0 && matchesAnyGlob(parameter.Name, c.allowDiffer) {
+				continue
+			}
+			k := key{group, parameter.Name}
+			if _, ok := values[k]; !ok {
+				keys = append(keys, k)
+				values[k] = map[string][]string{}
+			}
+			values[k][parameter.ActualValue] = append(values[k][parameter.ActualValue], stack)
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].group != keys[j].group {
+			return keys[i].group < keys[j].group
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	inconsistencies := []CfnParameterInconsistency{}
+	for _, k := range keys {
+		if len(values[k]) < 2 {
+			continue
+		}
+		inconsistency := CfnParameterInconsistency{Group: k.group, ParameterName: k.name}
+		for value, stacks := range values[k] {
+			inconsistency.Values = append(inconsistency.Values, CfnParameterValueStacks{Value: value, Stacks: stacks})
+		}
+		sort.Slice(inconsistency.Values, func(i, j int) bool {
+			a, b := inconsistency.Values[i], inconsistency.Values[j]
+			if len(a.Stacks) != len(b.Stacks) {
+				return len(a.Stacks) > len(b.Stacks)
+			}
+			return a.Value < b.Value
+		})
+		inconsistencies = append(inconsistencies, inconsistency)
+	}
+	return inconsistencies
+}
+
+func (c *ParametersCmd) toConsistencyCsvViews(inconsistencies []CfnParameterInconsistency) []CfnParameterConsistencyCsvView {
+	csvViews := []CfnParameterConsistencyCsvView{}
+	for _, inconsistency := range inconsistencies {
+		for _, value := range inconsistency.Values {
+			csvViews = append(csvViews, CfnParameterConsistencyCsvView{
+				Group:          inconsistency.Group,
+				ParameterName:  inconsistency.ParameterName,
+				ParameterValue: value.Value,
+				StackCount:     strconv.Itoa(len(value.Stacks)),
+				Stacks:         strings.Join(value.Stacks, ","),
+			})
+		}
+	}
+	return csvViews
+}
@@ -0,0 +1,39 @@
+package subcommands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParametersConsistency_GetInconsistencies(t *testing.T) {
+	parameters := func(env, size, cidr string) []CfnParameter {
+		return []CfnParameter{{Name: "Env", ActualValue: env}, {Name: "Size", ActualValue: size}, {Name: "Cidr", ActualValue: cidr}}
+	}
+	views := []*CfnParametersView{
+		{AccountId: "1", Region: "r1", StackName: "app", TemplateHash: "hash", Parameters: parameters("dev", "small", "10.0.0.0/16")},
+		{AccountId: "2", Region: "r1", StackName: "app", TemplateHash: "hash", Parameters: parameters("stg", "small", "10.0.0.0/16")},
+		{AccountId: "3", Region: "r1", StackName: "app", TemplateHash: "hash", Parameters: parameters("prd", "large", "10.0.0.0/16")},
+		// another template
+		{AccountId: "3", Region: "r1", StackName: "other", TemplateHash: "other", Parameters: parameters("prd", "medium", "10.1.0.0/16")},
+	}
+
+	cmd := ParametersCmd{allowDiffer: []string{"Env"}}
+	inconsistencies := cmd.GetInconsistencies(views)
+	assert.Equal(t, 1, len(inconsistencies))
+	assert.Equal(t, "Template:hash", inconsistencies[0].Group)
+	assert.Equal(t, "Size", inconsistencies[0].ParameterName)
+	assert.Equal(t, []CfnParameterValueStacks{
+		{Value: "small", Stacks: []string{"1/r1/app", "2/r1/app"}},
+		{Value: "large", Stacks: []string{"3/r1/app"}},
+	}, inconsistencies[0].Values)
+
+	csvViews := cmd.toConsistencyCsvViews(inconsistencies)
+	assert.Equal(t, 2, len(csvViews))
+	assert.Equal(t, "2", csvViews[0].StackCount)
+	assert.Equal(t, "1/r1/app,2/r1/app", csvViews[0].Stacks)
+
+	// without allow-lists, Env differs too
+	cmd = ParametersCmd{}
+	assert.Equal(t, 2, len(cmd.GetInconsistencies(views)))
+}
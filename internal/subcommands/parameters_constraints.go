@@ -0,0 +1,131 @@
+package subcommands
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// CfnParameterConstraints are constraints declared for a parameter in the template.
+// numbers are kept as strings and empty strings mean not declared.
+type CfnParameterConstraints struct {
+	AllowedValues         []string
+	AllowedPattern        string
+	MinLength             string
+	MaxLength             string
+	MinValue              string
+	MaxValue              string
+	ConstraintDescription string
+}
+
+// String returns the declared constraints except AllowedValues e.g. AllowedPattern=^[a-z]+$;MaxLength=10
+func (c CfnParameterConstraints) String() string {
+	constraints := []string{}
+	for _, constraint := range []struct{ name, value string }{
+		{"AllowedPattern", c.AllowedPattern},
+		{"MinLength", c.MinLength},
+		{"MaxLength", c.MaxLength},
+		{"MinValue", c.MinValue},
+		{"MaxValue", c.MaxValue},
+	} {
+		if constraint.value != "" {
+			constraints = append(constraints, fmt.Sprintf("%s=%s", constraint.name, constraint.value))
+		}
+	}
+	return strings.Join(constraints, ";")
+}
+
+// parseTemplateParameters returns constraints of parameters declared in the template body keyed by parameter names.
+// it returns an empty map if the template can't be parsed.
+func parseTemplateParameters(body string) map[string]CfnParameterConstraints {
+	constraints := map[string]CfnParameterConstraints{}
+	template, err := parseTemplate(body)
+	if err != nil {
+		return constraints
+	}
+	parameters, _ := template["Parameters"].(map[string]interface{})
+	for name, v := range parameters {
+		parameter, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		stringOf := func(key string) string {
+			s, _ := parameter[key].(string)
+			return s
+		}
+		c := CfnParameterConstraints{
+			AllowedPattern:        stringOf("AllowedPattern"),
+			MinLength:             stringOf("MinLength"),
+			MaxLength:             stringOf("MaxLength"),
+			MinValue:              stringOf("MinValue"),
+			MaxValue:              stringOf("MaxValue"),
+			ConstraintDescription: stringOf("ConstraintDescription"),
+		}
+		allowedValues, _ := parameter["AllowedValues"].([]interface{})
+		for _, allowedValue := range allowedValues {
+			if s, ok := allowedValue.(string); ok {
+				c.AllowedValues = append(c.AllowedValues, s)
+			}
+		}
+		constraints[name] = c
+	}
+	return constraints
+}
+
+// validateParameterValue returns violations of the actual value against the constraints joined by "; ",
+// or an empty string if it satisfies them. NoEcho and ssm parameters are not validated
+// because their actual values are masked or paths. values of list types are validated per item.
+func validateParameterValue(parameter CfnParameter) string {
+	if parameter.NoEcho || strings.HasPrefix(parameter.Type, "AWS::SSM::") {
+		return ""
+	}
+	values := []string{parameter.ActualValue}
+	if strings.HasPrefix(parameter.Type, "List<") || parameter.Type == "CommaDelimitedList" {
+		values = strings.Split(parameter.ActualValue, ",")
+	}
+
+	violations := []string{}
+	for _, value := range values {
+		violations = append(violations, validateParameterItem(parameter, strings.TrimSpace(value))...)
+	}
+	return strings.Join(violations, "; ")
+}
+
+func validateParameterItem(parameter CfnParameter, value string) []string {
+	violations := []string{}
+	constraints := parameter.Constraints
+
+	if len(constraints.AllowedValues) != 0 {
+		allowed := false
+		for _, allowedValue := range constraints.AllowedValues {
+			allowed = allowed || allowedValue == value
+		}
+		if !allowed {
+			violations = append(violations, fmt.Sprintf("%q is not in AllowedValues [%s]", value, strings.Join(constraints.AllowedValues, ", ")))
+		}
+	}
+	if constraints.AllowedPattern != "" {
+		// patterns not supported by go regexp are skipped
+		if pattern, err := regexp.Compile("^(?:" + constraints.AllowedPattern + ")$"); err == nil && !pattern.MatchString(value) {
+			violations = append(violations, fmt.Sprintf("%q does not match AllowedPattern %s", value, constraints.AllowedPattern))
+		}
+	}
+	length := utf8.RuneCountInString(value)
+	if min, err := strconv.Atoi(constraints.MinLength); err == nil && length < min {
+		violations = append(violations, fmt.Sprintf("%q is shorter than MinLength %d", value, min))
+	}
+	if max, err := strconv.Atoi(constraints.MaxLength); err == nil && length > max {
+		violations = append(violations, fmt.Sprintf("%q is longer than MaxLength %d", value, max))
+	}
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		if min, err := strconv.ParseFloat(constraints.MinValue, 64); err == nil && number < min {
+			violations = append(violations, fmt.Sprintf("%s is less than MinValue %s", value, constraints.MinValue))
+		}
+		if max, err := strconv.ParseFloat(constraints.MaxValue, 64); err == nil && number > max {
+			violations = append(violations, fmt.Sprintf("%s is greater than MaxValue %s", value, constraints.MaxValue))
+		}
+	}
+	return violations
+}
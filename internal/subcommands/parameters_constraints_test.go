@@ -0,0 +1,59 @@
+package subcommands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParametersConstraints_parseTemplateParameters(t *testing.T) {
+	constraints := parseTemplateParameters(`
+Parameters:
+  Env:
+    Type: String
+    AllowedValues: [dev, prd]
+  Name:
+    Type: String
+    AllowedPattern: "[a-z]+"
+    MinLength: 3
+    MaxLength: 10
+    ConstraintDescription: lower case letters only
+  Count:
+    Type: Number
+    MinValue: 1
+    MaxValue: 5.5
+`)
+	assert.Equal(t, []string{"dev", "prd"}, constraints["Env"].AllowedValues)
+	assert.Equal(t, "AllowedPattern=[a-z]+;MinLength=3;MaxLength=10", constraints["Name"].String())
+	assert.Equal(t, "lower case letters only", constraints["Name"].ConstraintDescription)
+	assert.Equal(t, "MinValue=1;MaxValue=5.5", constraints["Count"].String())
+
+	assert.Equal(t, 0, len(parseTemplateParameters("{invalid")))
+}
+
+func TestParametersConstraints_validateParameterValue(t *testing.T) {
+	parameter := func(parameterType, value string, constraints CfnParameterConstraints) CfnParameter {
+		return CfnParameter{Type: parameterType, ActualValue: value, Constraints: constraints}
+	}
+
+	envConstraints := CfnParameterConstraints{AllowedValues: []string{"dev", "prd"}}
+	assert.Equal(t, "", validateParameterValue(parameter("String", "dev", envConstraints)))
+	assert.Equal(t, `"stg" is not in AllowedValues [dev, prd]`, validateParameterValue(parameter("String", "stg", envConstraints)))
+	assert.Equal(t, `"stg" is not in AllowedValues [dev, prd]`, validateParameterValue(parameter("CommaDelimitedList", "dev, stg", envConstraints)))
+
+	nameConstraints := CfnParameterConstraints{AllowedPattern: "[a-z]+", MinLength: "3", MaxLength: "5"}
+	assert.Equal(t, "", validateParameterValue(parameter("String", "abc", nameConstraints)))
+	assert.Equal(t, `"ab" is shorter than MinLength 3`, validateParameterValue(parameter("String", "ab", nameConstraints)))
+	assert.Equal(t, `"ABCDEF" does not match AllowedPattern [a-z]+; "ABCDEF" is longer than MaxLength 5`, validateParameterValue(parameter("String", "ABCDEF", nameConstraints)))
+
+	countConstraints := CfnParameterConstraints{MinValue: "1", MaxValue: "5"}
+	assert.Equal(t, "", validateParameterValue(parameter("Number", "5", countConstraints)))
+	assert.Equal(t, "6 is greater than MaxValue 5", validateParameterValue(parameter("Number", "6", countConstraints)))
+	assert.Equal(t, "0 is less than MinValue 1", validateParameterValue(parameter("List<Number>", "1,0", countConstraints)))
+
+	// masked and ssm values are not validated
+	secret := parameter("String", "****", envConstraints)
+	secret.NoEcho = true
+	assert.Equal(t, "", validateParameterValue(secret))
+	assert.Equal(t, "", validateParameterValue(parameter("AWS::SSM::Parameter::Value<String>", "/path", envConstraints)))
+}
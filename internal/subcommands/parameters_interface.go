@@ -0,0 +1,64 @@
+package subcommands
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// CfnParameterInterface is the AWS::CloudFormation::Interface metadata of a template,
+// which defines how parameters are grouped and labeled in the console.
+type CfnParameterInterface struct {
+	ParameterGroups []struct {
+		Label struct {
+			Default string `json:"default"`
+		}
+		Parameters []string
+	}
+	ParameterLabels map[string]struct {
+		Default string `json:"default"`
+	}
+}
+
+// parseParameterInterface parses the template metadata returned by GetTemplateSummary.
+// it returns an empty interface if the metadata is empty or invalid.
+func parseParameterInterface(metadata string) *CfnParameterInterface {
+	var m struct {
+		Interface CfnParameterInterface `json:"AWS::CloudFormation::Interface"`
+	}
+	if metadata != "" {
+		_ = json.Unmarshal([]byte(metadata), &m)
+	}
+	return &m.Interface
+}
+
+// apply sets the group, label and order of the parameter. parameters not in any groups are ordered last
+// as the console lists them under "Other parameters".
+func (i *CfnParameterInterface) apply(parameter *CfnParameter) {
+	parameter.Label = i.ParameterLabels[parameter.Name].Default
+	parameter.groupOrder = math.MaxInt32
+	order := 0
+	for _, group := range i.ParameterGroups {
+		for _, name := range group.Parameters {
+			if name == parameter.Name && parameter.Group == "" {
+				parameter.Group = group.Label.Default
+				parameter.groupOrder = order
+			}
+			order++
+		}
+	}
+}
+
+// sortParametersByGroup returns copies of the views whose parameters are ordered by groups the way the console does.
+func sortParametersByGroup(views []*CfnParametersView) []*CfnParametersView {
+	sortedViews := []*CfnParametersView{}
+	for _, view := range views {
+		sortedView := *view
+		sortedView.Parameters = append([]CfnParameter{}, view.Parameters...)
+		sort.SliceStable(sortedView.Parameters, func(i, j int) bool {
+			return sortedView.Parameters[i].groupOrder < sortedView.Parameters[j].groupOrder
+		})
+		sortedViews = append(sortedViews, &sortedView)
+	}
+	return sortedViews
+}
@@ -0,0 +1,48 @@
+package subcommands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParametersInterface_sortParametersByGroup(t *testing.T) {
+	metadata := `{
+  "AWS::CloudFormation::Interface": {
+    "ParameterGroups": [
+      {"Label": {"default": "Network"}, "Parameters": ["VpcId", "SubnetIds"]},
+      {"Label": {"default": "Application"}, "Parameters": ["Env"]}
+    ],
+    "ParameterLabels": {"VpcId": {"default": "Which VPC?"}}
+  }
+}`
+	parameterInterface := parseParameterInterface(metadata)
+
+	parameters := []CfnParameter{{Name: "Other"}, {Name: "Env"}, {Name: "SubnetIds"}, {Name: "VpcId"}}
+	for i := range parameters {
+		parameterInterface.apply(&parameters[i])
+	}
+	assert.Equal(t, "Network", parameters[3].Group)
+	assert.Equal(t, "Which VPC?", parameters[3].Label)
+	assert.Equal(t, "", parameters[0].Group)
+
+	views := []*CfnParametersView{{StackName: "stack", Parameters: parameters}}
+	sortedViews := sortParametersByGroup(views)
+	names := []string{}
+	for _, parameter := range sortedViews[0].Parameters {
+		names = append(names, parameter.Name)
+	}
+	assert.Equal(t, []string{"VpcId", "SubnetIds", "Env", "Other"}, names)
+	// original views are kept in declaration order
+	assert.Equal(t, "Other", views[0].Parameters[0].Name)
+
+	cmd := ParametersCmd{}
+	table := cmd.toTable(sortedViews)
+	assert.Equal(t, []string{"ParameterName", "ParameterLabel", "ParameterGroup"}, table.header[4:7])
+	assert.Equal(t, []interface{}{"VpcId", "Which VPC?", "Network"}, table.records[0][4:7])
+
+	// templates without the metadata
+	parameter := CfnParameter{Name: "Env"}
+	parseParameterInterface("").apply(&parameter)
+	assert.Equal(t, "", parameter.Group)
+}
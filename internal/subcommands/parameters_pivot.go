@@ -0,0 +1,148 @@
+package subcommands
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+const (
+	// length of template hash prefixes used as group names
+	TEMPLATE_HASH_PREFIX_LENGTH = 12
+)
+
+type CfnParameterPivotRow struct {
+	Group           string
+	ParameterName   string
+	MostCommonValue string
+	// Values are actual values keyed by columns
+	Values map[string]string
+	// Differs are columns whose values differ from the most common value
+	Differs map[string]bool
+}
+
+type CfnParameterPivot struct {
+	Columns []string
+	Rows    []CfnParameterPivotRow
+}
+
+// GetPivot pivots parameters into a row per stack group and parameter, and a column per account and region.
+// if a group has several stacks at the same account and region, the stack name is appended to their columns.
+func (c *ParametersCmd) GetPivot(views []*CfnParametersView) *CfnParameterPivot {
+	pivot := &CfnParameterPivot{Columns: []string{}, Rows: []CfnParameterPivotRow{}}
+
+	columns := map[string]bool{}
+	// columns claimed by stacks per group
+	claimed := map[string]map[string]string{}
+	rowIndices := map[[2]string]int{}
+	for _, view := range views {
+		if view.StackName == "" {
+			continue
+		}
+		group := c.stackGroupOf(view)
+		accountLabel := view.AccountName
+		if accountLabel == "" {
+			accountLabel = view.AccountId
+		}
+		column := fmt.Sprintf("%s/%s", accountLabel, view.Region)
+		if claimed[group] == nil {
+			claimed[group] = map[string]string{}
+		}
+		if stackName, ok := claimed[group][column]; ok && stackName != view.StackName {
+			column = fmt.Sprintf("%s/%s", column, view.StackName)
+		}
+		claimed[group][column] = view.StackName
+		columns[column] = true
+
+		for _, parameter := range view.Parameters {
+			key := [2]string{group, parameter.Name}
+			i, ok := rowIndices[key]
+			if !ok {
+				i = len(pivot.Rows)
+				rowIndices[key] = i
+				pivot.Rows = append(pivot.Rows, CfnParameterPivotRow{
+					Group:         group,
+					ParameterName: parameter.Name,
+					Values:        map[string]string{},
+					Differs:       map[string]bool{},
+				})
+			}
+			pivot.Rows[i].Values[column] = parameter.ActualValue
+		}
+	}
+
+	for column := range columns {
+		pivot.Columns = append(pivot.Columns, column)
+	}
+	sort.Strings(pivot.Columns)
+
+	for i := range pivot.Rows {
+		row := &pivot.Rows[i]
+		row.MostCommonValue = mostCommonValue(row.Values)
+		for column, value := range row.Values {
+			if value != row.MostCommonValue {
+				row.Differs[column] = true
+			}
+		}
+	}
+	sort.SliceStable(pivot.Rows, func(i, j int) bool {
+		if pivot.Rows[i].Group != pivot.Rows[j].Group {
+			return pivot.Rows[i].Group < pivot.Rows[j].Group
+		}
+		return pivot.Rows[i].ParameterName < pivot.Rows[j].ParameterName
+	})
+
+	return pivot
+}
+
+// toTable converts the pivot into a table whose cells differing from the most common values are highlighted.
+func (p *CfnParameterPivot) toTable() *table {
+	t := &table{header: append([]string{"Group", "ParameterName", "MostCommonValue"}, p.Columns...)}
+	offset := len(t.header) - len(p.Columns)
+	for r, row := range p.Rows {
+		record := []interface{}{row.Group, row.ParameterName, row.MostCommonValue}
+		for i, column := range p.Columns {
+			record = append(record, row.Values[column])
+			if row.Differs[column] {
+				t.highlight(r, offset+i)
+			}
+		}
+		t.records = append(t.records, record)
+	}
+	return t
+}
+
+// stackGroupOf returns Pattern:<pattern> for the first name pattern matching the stack,
+// otherwise Template:<template hash prefix>, so that stacks sharing the same template are grouped.
+func (c *ParametersCmd) stackGroupOf(view *CfnParametersView) string {
+	for _, pattern := range c.namePatterns {
+		if matched, _ := regexp.MatchString(pattern, view.StackName); matched {
+			return "Pattern:" + pattern
+		}
+	}
+	if view.TemplateHash == "" {
+		return "Stack:" + view.StackName
+	}
+	hash := view.TemplateHash
+	if len(hash) > TEMPLATE_HASH_PREFIX_LENGTH {
+		hash = hash[:TEMPLATE_HASH_PREFIX_LENGTH]
+	}
+	return "Template:" + hash
+}
+
+// mostCommonValue returns the value appearing most often. ties are broken by the smallest value.
+func mostCommonValue(values map[string]string) string {
+	counts := map[string]int{}
+	for _, value := range values {
+		counts[value]++
+	}
+	mostCommon := ""
+	maxCount := 0
+	for value, count := range counts {
+		if count > maxCount || (count == maxCount && value < mostCommon) {
+			mostCommon = value
+			maxCount = count
+		}
+	}
+	return mostCommon
+}
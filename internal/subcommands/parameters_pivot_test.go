@@ -0,0 +1,33 @@
+package subcommands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParametersPivot_GetPivot(t *testing.T) {
+	views := []*CfnParametersView{
+		{AccountId: "1", AccountName: "dev", Region: "r1", StackName: "app", TemplateHash: "0123456789abcdef", Parameters: []CfnParameter{{Name: "Size", ActualValue: "small"}}},
+		{AccountId: "1", AccountName: "dev", Region: "r1", StackName: "app-2", TemplateHash: "0123456789abcdef", Parameters: []CfnParameter{{Name: "Size", ActualValue: "small"}}},
+		{AccountId: "2", AccountName: "", Region: "r1", StackName: "app", TemplateHash: "0123456789abcdef", Parameters: []CfnParameter{{Name: "Size", ActualValue: "large"}}},
+		{AccountId: "2", AccountName: "", Region: "r1", StackName: "network-prd", TemplateHash: "fedcba9876543210", Parameters: []CfnParameter{{Name: "Cidr", ActualValue: "10.0.0.0/16"}}},
+		{AccountId: "3", Region: "r1", Error: assert.AnError},
+	}
+
+	cmd := ParametersCmd{namePatterns: []string{"^network-"}}
+	pivot := cmd.GetPivot(views)
+	assert.Equal(t, []string{"2/r1", "dev/r1", "dev/r1/app-2"}, pivot.Columns)
+	assert.Equal(t, 2, len(pivot.Rows))
+
+	assert.Equal(t, "Pattern:^network-", pivot.Rows[0].Group)
+	assert.Equal(t, "10.0.0.0/16", pivot.Rows[0].Values["2/r1"])
+
+	assert.Equal(t, "Template:0123456789ab", pivot.Rows[1].Group)
+	assert.Equal(t, "small", pivot.Rows[1].MostCommonValue)
+	assert.Equal(t, map[string]bool{"2/r1": true}, pivot.Rows[1].Differs)
+
+	table := pivot.toTable()
+	assert.Equal(t, []string{"Group", "ParameterName", "MostCommonValue", "2/r1", "dev/r1", "dev/r1/app-2"}, table.header)
+	assert.Equal(t, map[[2]int]bool{{1, 3}: true}, table.highlights)
+}
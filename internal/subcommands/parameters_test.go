@@ -4,6 +4,8 @@ import (
 	"os"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/gookit/config/v2"
 	cfnConfig "github.com/horietakehiro/cfn-global-views/config"
 	"github.com/stretchr/testify/assert"
@@ -65,25 +67,7 @@ func writeTmpYaml(body string) {
 }
 
 func TestParameters_invalid(t *testing.T) {
-	defer config.ClearAll()
-	defer func() { os.Remove(TMP_CONFIG_PATH) }()
-
-	tmpConfigYaml := `
-RootConfig:
-  Credential:
-    Type: "CLI"
-    ProfileName: not-exist-profile
-  Filters:
-    Regions:
-      - "not-exist-region"
-AccountConfigs:
-  - Name: main-account
-    Id: 123456789012
-`
-	writeTmpYaml(tmpConfigYaml)
-
-	c, err := cfnConfig.GetConfig(TMP_CONFIG_PATH)
-	assert.Nil(t, err)
+	c := getInvalidConfig(t, "")
 
 	cmd := ParametersCmd{
 		config: c,
@@ -95,3 +79,89 @@ AccountConfigs:
 	assert.NotNil(t, views[0].Error, views[0])
 
 }
+
+func TestParameters_tag_columns(t *testing.T) {
+	views := []*CfnParametersView{
+		{
+			StackName:  "stack-1",
+			StackTags:  []CfnTag{{Key: "ENV", Value: "test"}, {Key: "Owner", Value: "me"}},
+			Parameters: []CfnParameter{{Name: "param-1"}, {Name: "param-2"}},
+		},
+		{
+			StackName: "stack-2",
+		},
+	}
+
+	cmd := ParametersCmd{tagColumns: []string{"Owner", "CostCenter"}}
+	table := cmd.toTable(views)
+	assert.Equal(t, "StackName", table.header[3])
+	assert.Equal(t, "Tag:Owner", table.header[4])
+	assert.Equal(t, "Tag:CostCenter", table.header[5])
+	assert.Equal(t, "ParameterName", table.header[6])
+	assert.Equal(t, 3, len(table.records))
+	assert.Equal(t, "me", table.records[1][4])
+	assert.Equal(t, "", table.records[1][5])
+	assert.Equal(t, "param-2", table.records[1][6])
+	assert.Equal(t, "", table.records[2][4])
+}
+
+func TestParameters_provenance(t *testing.T) {
+	stackParameters := []*cloudformation.Parameter{
+		{ParameterKey: aws.String("Default"), ParameterValue: aws.String("default")},
+		{ParameterKey: aws.String("Override"), ParameterValue: aws.String("override")},
+		{ParameterKey: aws.String("NoDefault"), ParameterValue: aws.String("value")},
+		{ParameterKey: aws.String("Ssm"), ParameterValue: aws.String("/path/to/ami"), ResolvedValue: aws.String("ami-0123")},
+		{ParameterKey: aws.String("Secret"), ParameterValue: aws.String("****")},
+	}
+	declaration := func(key, defaultValue string, noEcho bool) *cloudformation.ParameterDeclaration {
+		d := &cloudformation.ParameterDeclaration{ParameterKey: aws.String(key), ParameterType: aws.String("String"), NoEcho: aws.Bool(noEcho)}
+		if defaultValue != "" {
+			d.DefaultValue = aws.String(defaultValue)
+		}
+		return d
+	}
+
+	assert.Equal(t, PARAMETER_PROVENANCE_DEFAULT, newCfnParameter(declaration("Default", "default", false), stackParameters).Provenance)
+	assert.Equal(t, PARAMETER_PROVENANCE_OVERRIDE, newCfnParameter(declaration("Override", "default", false), stackParameters).Provenance)
+	assert.Equal(t, PARAMETER_PROVENANCE_OVERRIDE, newCfnParameter(declaration("NoDefault", "", false), stackParameters).Provenance)
+	assert.Equal(t, PARAMETER_PROVENANCE_NO_ECHO, newCfnParameter(declaration("Secret", "secret", true), stackParameters).Provenance)
+
+	ssm := newCfnParameter(declaration("Ssm", "/path/to/ami", false), stackParameters)
+	assert.Equal(t, PARAMETER_PROVENANCE_SSM_RESOLVED, ssm.Provenance)
+	assert.Equal(t, "/path/to/ami", ssm.ActualValue)
+	assert.Equal(t, "ami-0123", ssm.ResolvedValue)
+
+	// NoEcho parameters are masked even if they have default values
+	cmd := ParametersCmd{nonDefault: true}
+	assert.False(t, cmd.listed(newCfnParameter(declaration("Default", "default", false), stackParameters)))
+	assert.True(t, cmd.listed(newCfnParameter(declaration("Override", "default", false), stackParameters)))
+	assert.True(t, cmd.listed(newCfnParameter(declaration("NoDefault", "", false), stackParameters)))
+	assert.True(t, cmd.listed(newCfnParameter(declaration("Secret", "****", true), stackParameters)))
+	// ssm parameters still at their default paths are not listed
+	assert.False(t, cmd.listed(ssm))
+}
+
+func TestParameters_non_default_report(t *testing.T) {
+	views := []*CfnParametersView{
+		{AccountId: "1", Region: "r1", StackName: "app", Parameters: []CfnParameter{
+			{Name: "Size", DefaultValue: "small", ActualValue: "small", hasDefault: true},
+			{Name: "Env", DefaultValue: "dev", ActualValue: "prd", hasDefault: true},
+		}},
+		{AccountId: "2", Region: "r1", StackName: "app", Parameters: []CfnParameter{
+			{Name: "Size", DefaultValue: "small", ActualValue: "small", hasDefault: true},
+		}},
+	}
+
+	cmd := ParametersCmd{nonDefault: true, pivot: true}
+	report := cmd.Report(views)
+	// stacks whose parameters are all filtered out are still listed
+	list := report.Tables[0]
+	assert.Equal(t, 2, len(list.Records))
+	assert.Equal(t, "Env", list.Records[0][4])
+	assert.Equal(t, "", list.Records[1][4])
+	// the pivot is made from all parameters
+	pivot := report.Tables[1].Data.(*CfnParameterPivot)
+	assert.Equal(t, 2, len(pivot.Rows))
+	// views aren't modified by filtering
+	assert.Equal(t, 2, len(views[0].Parameters))
+}
@@ -2,34 +2,40 @@ package subcommands
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
-	"os"
-	"reflect"
-	"regexp"
-	"sort"
-	"strconv"
 
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudcontrolapi"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/gocarina/gocsv"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
 	"github.com/google/subcommands"
 	"github.com/horietakehiro/cfn-global-views/config"
-	"github.com/schollz/progressbar/v3"
-	"github.com/xuri/excelize/v2"
+	"github.com/horietakehiro/cfn-global-views/output"
 	"golang.org/x/exp/slog"
 )
 
 type CfnResource struct {
-	PhysicalId  string
-	LogicalId   string
-	Type        string
+	PhysicalId string
+	LogicalId  string
+	Type       string
+	// Description is kept for compatibility, and always empty since ListStackResources doesn't return it
 	Description string
 	Status      string
-	DriftStatus string
+	// StatusReason is the reason of the current status e.g. the error message of a failed operation
+	StatusReason         string
+	LastUpdatedTimestamp string
+	// ModuleTypeHierarchy and ModuleLogicalIdHierarchy are set if the resource is created by modules
+	ModuleTypeHierarchy      string
+	ModuleLogicalIdHierarchy string
+	DriftStatus              string
+	DriftLastCheckTimestamp  string
+	// Arn and ConsoleUrl are set only if links are requested
+	Arn        string `json:",omitempty"`
+	ConsoleUrl string `json:",omitempty"`
+	// Properties are the current properties got from cloud control api if the resource type is enriched
+	Properties      map[string]interface{} `json:",omitempty"`
+	PropertiesError string                 `json:",omitempty"`
 }
 
 type CfnResourcesView struct {
@@ -37,22 +43,39 @@ type CfnResourcesView struct {
 	AccountName string
 	Region      string
 	StackName   string
-	Resources   []CfnResource
-	Error       error
+	StackTags   []CfnTag
+	// TemplateHash is the fingerprint of the normalized original template
+	TemplateHash string
+	// StackConsoleUrl is set only if links are requested
+	StackConsoleUrl string `json:",omitempty"`
+	// DeclaredResourceTypes are resource types declared in the template, got only for summaries
+	DeclaredResourceTypes []string `json:",omitempty"`
+	// DeployedResourceTypes are resource types of all resources regardless of resource filters, got only for summaries
+	DeployedResourceTypes []string `json:",omitempty"`
+	// SummaryError is set if the template summary failed to be got, in which case DeclaredResourceTypes are unknown
+	SummaryError string `json:",omitempty"`
+	Resources    []CfnResource
+	Error        error
 }
 
 type CfnResourcesCsvView struct {
-	AccountId           string
-	AccountName         string
-	Region              string
-	StackName           string
-	ResourcePhysicalId  string
-	ResourceLogicalId   string
-	ResourceType        string
-	ResourceDescription string
-	ResourceStatus      string
-	ResourceDriftStatus string
-	Error               string
+	AccountId                        string
+	AccountName                      string
+	Region                           string
+	StackName                        string
+	ResourcePhysicalId               string
+	ResourceLogicalId                string
+	ResourceType                     string
+	ResourceDescription              string
+	ResourceStatus                   string
+	ResourceStatusReason             string
+	ResourceLastUpdatedTimestamp     string
+	ResourceModuleTypeHierarchy      string
+	ResourceModuleLogicalIdHierarchy string
+	ResourceDriftStatus              string
+	ResourceDriftLastCheckTimestamp  string
+	StackTemplateHash                string
+	Error                            string
 }
 
 type ResourcesCmd struct {
@@ -61,8 +84,16 @@ type ResourcesCmd struct {
 	outFilePath    string
 	format         string
 	verbose        bool
+	tagColumns     []string
+	enrich         bool
+	resourceStatus []string
+	resourceType   []string
+	summary        bool
+	links          bool
 	logger         *slog.Logger
 	config         *config.CfnGlobalViewsConfig
+	templates      *templateCache
+	collected      func(views []*CfnResourcesView)
 }
 
 func (*ResourcesCmd) Name() string {
@@ -72,68 +103,60 @@ func (*ResourcesCmd) Synopsis() string {
 	return "list cfn resources"
 }
 func (*ResourcesCmd) Usage() string {
-	return "resources -c path/to/config.yaml"
+	return "resources -c path/to/config.yaml [-resource-status *_FAILED] [-resource-type AWS::Lambda::*] [-summary] [-enrich] [-links]"
 }
 func (c *ResourcesCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
 	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
-	f.StringVar(&c.format, "f", "csv", "output data format [csv, json, excel] (default is csv)")
+	f.StringVar(&c.format, "f", "csv", formatUsage("csv"))
 	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+	f.Var((*listFlag)(&c.tagColumns), "tag-columns", "comma separated stack tag keys to add as columns to csv and excel outputs e.g. ENV,Owner,CostCenter")
+	f.Var((*listFlag)(&c.resourceStatus), "resource-status", "comma separated glob patterns of resource statuses to list e.g. *_FAILED,UPDATE_ROLLBACK_*")
+	f.Var((*listFlag)(&c.resourceType), "resource-type", "comma separated glob patterns of resource types to list e.g. AWS::Lambda::*,AWS::IAM::Role")
+	f.BoolVar(&c.summary, "summary", false, "if set, output counts of resources per resource type and account and region compared with resource types declared in templates. excel output has both sheets")
+	f.BoolVar(&c.links, "links", false, "if set, add arns and console urls of resources and stacks. excel output shows urls as hyperlinks")
+	f.BoolVar(&c.enrich, "enrich", false, "if set, get current properties of resources whose types are configured at ResourceEnrichments with cloud control api")
 }
 
 func (c *ResourcesCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
 	var err error
-	if c.configFilePath == "" {
-		fmt.Println("arg '-c path/to/config.yaml' is required")
-		return subcommands.ExitFailure
-	}
-	if c.format != "csv" && c.format != "json" && c.format != "excel" {
-		fmt.Println("allowed values for arg '-f' are [csv, json, excel]")
-		return subcommands.ExitFailure
-	}
-	if c.format == "excel" && c.outFilePath == "" {
-		fmt.Println("if format is excel, must specify output file path arg '-o'")
-		return subcommands.ExitFailure
-	}
-
-	if c.verbose {
-		c.logger = slog.New(slog.NewJSONHandler(os.Stdout))
-	} else {
-		c.logger = slog.New(slog.NewJSONHandler(io.Discard))
-	}
 
-	c.config, err = config.GetConfig(c.configFilePath)
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, nil)
 	if err != nil {
 		fmt.Println(err.Error())
 		return subcommands.ExitFailure
 	}
+	if c.enrich && len(c.config.ResourceEnrichments) == 0 {
+		fmt.Println("if arg '-enrich' is set, must specify ResourceEnrichments in config file")
+		return subcommands.ExitFailure
+	}
 
-	globalViews := c.GetGlobalViews()
+	return exitStatus(c.logger, writeGlobalViews(c.format, c.outFilePath, &c.collected, c.GetGlobalViews, c.Report))
+}
 
-	if c.format == "csv" {
-		err = c.DumpCsv(globalViews)
-		if err != nil {
-			return subcommands.ExitFailure
-		}
-	}
-	if c.format == "json" {
-		err = c.DumpJson(globalViews)
-		if err != nil {
-			return subcommands.ExitFailure
-		}
-	}
-	if c.format == "excel" {
-		err = c.DumpExcel(globalViews)
-		if err != nil {
-			return subcommands.ExitFailure
-		}
+// Report has resources, and the summary as the main table if requested.
+func (c *ResourcesCmd) Report(views []*CfnResourcesView) *output.Report {
+	report := &output.Report{Tables: []*output.Table{c.toTable(views).toOutput(c.Name(), views)}}
+	if c.summary {
+		summary := c.GetSummary(views)
+		report.Tables = append(report.Tables, summary.toTable().toOutput(c.Name()+"-summary", summary))
+		report.Main = 1
+		report.Aggregated = true
 	}
-
-	return subcommands.ExitSuccess
+	return report
 }
 
-func (c *ResourcesCmd) DumpExcel(views []*CfnResourcesView) error {
+func (c *ResourcesCmd) toTable(views []*CfnResourcesView) *table {
 	csvViews := []CfnResourcesCsvView{}
+	rowTags := [][]CfnTag{}
+	var resourceEnrichments []config.ResourceEnrichment
+	if c.enrich {
+		resourceEnrichments = c.config.ResourceEnrichments
+	}
+	paths := propertyColumns(resourceEnrichments)
+	rowProperties := [][]string{}
+	rowStackLinks := [][]string{}
+	rowLinks := [][]string{}
 
 	for _, view := range views {
 		var errorString string
@@ -154,394 +177,192 @@ func (c *ResourcesCmd) DumpExcel(views []*CfnResourcesView) error {
 				ResourceDescription: "",
 				ResourceStatus:      "",
 				ResourceDriftStatus: "",
+				StackTemplateHash:   view.TemplateHash,
 				Error:               errorString,
 			})
+			rowTags = append(rowTags, view.StackTags)
+			rowProperties = append(rowProperties, append(make([]string, len(paths)), ""))
+			rowStackLinks = append(rowStackLinks, []string{view.StackConsoleUrl})
+			rowLinks = append(rowLinks, []string{"", ""})
 		}
 		for _, resource := range view.Resources {
 			csvViews = append(csvViews, CfnResourcesCsvView{
-				AccountId:           view.AccountId,
-				AccountName:         view.AccountName,
-				Region:              view.Region,
-				StackName:           view.StackName,
-				ResourcePhysicalId:  resource.PhysicalId,
-				ResourceLogicalId:   resource.LogicalId,
-				ResourceType:        resource.Type,
-				ResourceDescription: resource.Description,
-				ResourceStatus:      resource.Status,
-				ResourceDriftStatus: resource.DriftStatus,
-				Error:               errorString,
+				AccountId:                        view.AccountId,
+				AccountName:                      view.AccountName,
+				Region:                           view.Region,
+				StackName:                        view.StackName,
+				ResourcePhysicalId:               resource.PhysicalId,
+				ResourceLogicalId:                resource.LogicalId,
+				ResourceType:                     resource.Type,
+				ResourceDescription:              resource.Description,
+				ResourceStatus:                   resource.Status,
+				ResourceStatusReason:             resource.StatusReason,
+				ResourceLastUpdatedTimestamp:     resource.LastUpdatedTimestamp,
+				ResourceModuleTypeHierarchy:      resource.ModuleTypeHierarchy,
+				ResourceModuleLogicalIdHierarchy: resource.ModuleLogicalIdHierarchy,
+				ResourceDriftStatus:              resource.DriftStatus,
+				ResourceDriftLastCheckTimestamp:  resource.DriftLastCheckTimestamp,
+				StackTemplateHash:                view.TemplateHash,
+				Error:                            errorString,
 			})
+			rowTags = append(rowTags, view.StackTags)
+			rowProperties = append(rowProperties, append(propertyValues(resourceEnrichments, resource, paths), resource.PropertiesError))
+			rowStackLinks = append(rowStackLinks, []string{view.StackConsoleUrl})
+			rowLinks = append(rowLinks, []string{resource.Arn, resource.ConsoleUrl})
 		}
 	}
 
-	var file *excelize.File
-	if _, err := os.Stat(c.outFilePath); err == nil {
-		file, err = excelize.OpenFile(c.outFilePath, excelize.Options{})
-		if err != nil {
-			c.logger.Error(err.Error(), err)
+	t := newTable(csvViews)
+	if c.enrich {
+		columns := []string{}
+		for _, path := range paths {
+			columns = append(columns, "Property:"+path)
 		}
-	} else {
-		file = excelize.NewFile()
+		t.insertColumns("ResourceDriftLastCheckTimestamp", append(columns, "ResourcePropertiesError"), rowProperties)
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			fmt.Println(err)
-		}
-	}()
-
-	sheetName := c.Name()
-	index, err := file.NewSheet(sheetName)
-	if err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
+	if c.links {
+		t.insertColumns("StackName", []string{"StackConsoleUrl"}, rowStackLinks)
+		t.insertColumns("ResourcePhysicalId", []string{"ResourceArn", "ResourceConsoleUrl"}, rowLinks)
+		t.link("StackConsoleUrl", "ResourceConsoleUrl")
 	}
-	file.SetActiveSheet(index)
+	t.insertTagColumns(c.tagColumns, rowTags)
+	return t
+}
 
-	disable := false
-	numFileds := reflect.TypeOf(csvViews[0]).NumField()
-	startCol := 'B'
-	endCol := startCol + rune(numFileds) - 1
-	startRow := 2
-	endRow := startRow + len(csvViews) - 1
-	startCell := fmt.Sprintf("%s%s", string(startCol), strconv.Itoa(startRow))
-	endCell := fmt.Sprintf("%s%s", string(endCol), strconv.Itoa(endRow))
+func (c *ResourcesCmd) GetGlobalViews() []*CfnResourcesView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, c.collected)
 
-	// create table
-	err = file.AddTable(sheetName, fmt.Sprintf("%s:%s", startCell, endCell), &excelize.TableOptions{
-		Name:              sheetName,
-		StyleName:         "TableStyleMedium2",
-		ShowFirstColumn:   true,
-		ShowLastColumn:    true,
-		ShowRowStripes:    &disable,
-		ShowColumnStripes: true,
+	sortViews(globalViews, func(view *CfnResourcesView) []string {
+		return []string{view.AccountId, view.Region, view.StackName}
 	})
-	if err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
-	}
 
-	// write table column names
-	curField := 0
-	for curCol := startCol; curCol <= endCol; curCol++ {
-		curCell := fmt.Sprintf("%s%s", string(curCol), strconv.Itoa(startRow))
-		file.SetCellValue(sheetName, curCell, reflect.TypeOf(csvViews[0]).Field(curField).Name)
-		curField++
-	}
-	// write table cell values
-	curRowIndex := 0
-	for curRow := startRow + 1; curRow <= endRow; curRow++ {
-		t := reflect.TypeOf(csvViews[curRowIndex])
-		v := reflect.ValueOf(csvViews[curRowIndex])
-		curColIndex := 0
-		for curCol := startCol; curCol <= endCol; curCol++ {
-			curCell := fmt.Sprintf("%s%s", string(curCol), strconv.Itoa(curRow))
-			curField := t.Field(curColIndex).Name
-			curVal := v.FieldByName(curField).String()
-			file.SetCellValue(sheetName, curCell, curVal)
-			curColIndex++
-		}
-		curRowIndex++
-	}
+	return globalViews
+}
 
-	// set styles
-	style, err := file.NewStyle(&excelize.Style{
-		Alignment: &excelize.Alignment{
-			ShrinkToFit:     true,
-			Horizontal:      "left",
-			JustifyLastLine: true,
-			WrapText:        true,
-			Vertical:        "center",
-		},
-	})
-	if err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
-	}
-	err = file.SetCellStyle(sheetName, startCell, endCell, style)
-	if err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
+func (c *ResourcesCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnResourcesView {
+	views := []*CfnResourcesView{}
+	c.logger.Info("get cfn views", "accountId", accountConfig.Id, "region", region)
 
+	sess := newSession(accountConfig, region)
+	cfn := cloudformation.New(sess)
+
+	matchedStacks, err := describeMatchedStacks(cfn, accountConfig.Filters)
+	if err != nil {
+		return append(views, &CfnResourcesView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Error:       err,
+		})
 	}
-	curField = 0
-	for curCol := startCol; curCol <= endCol; curCol++ {
 
-		maxLength := 0
-		for _, v := range csvViews {
-			if l := len(reflect.ValueOf(v).Field(curField).String()); l > maxLength {
-				maxLength = l
+	// describe matched stacks' resources
+	for _, matchedStack := range matchedStacks {
+		c.logger.Info(fmt.Sprintf("matched cfn stack: %s", *matchedStack.StackName), "accountId", accountConfig.Id, "region", region)
+		stackResources, err := listStackResources(cfn, *matchedStack.StackId)
+		if err != nil {
+			views = append(views, &CfnResourcesView{
+				AccountId:   accountConfig.Id,
+				AccountName: accountConfig.Name,
+				Region:      region,
+				StackName:   *matchedStack.StackName,
+				StackTags:   newCfnTags(matchedStack.Tags),
+				Error:       err,
+			})
+			continue
+		}
+		var resources []CfnResource
+		for _, resource := range stackResources {
+			cfnResource := newCfnResource(resource)
+			if !matchesAnyGlob(cfnResource.Status, c.resourceStatus) || !matchesAnyGlob(cfnResource.Type, c.resourceType) {
+				continue
 			}
+			resources = append(resources, cfnResource)
 		}
-		var colWidth int
-		if maxLength >= 50 {
-			colWidth = 50
-		} else if maxLength <= len(reflect.TypeOf(csvViews[0]).Field(curField).Name) {
-			colWidth = len(reflect.TypeOf(csvViews[0]).Field(curField).Name) + 5
-		} else {
-			colWidth = maxLength
+		// with resource filters, stacks having no matched resources are just noise
+		// except for summaries, which report stacks lacking declared resource types
+		if len(resources) == 0 && (len(c.resourceStatus) != 0 || len(c.resourceType) != 0) && !c.summary {
+			continue
 		}
-		err = file.SetColWidth(sheetName, string(curCol), string(curCol), float64(colWidth))
-		if err != nil {
-			c.logger.Error(err.Error(), err)
-			return err
+		stackUrl := ""
+		if c.links {
+			stackUrl = stackConsoleUrl(region, *matchedStack.StackId)
+			setResourceLinks(accountConfig.Id, region, resources)
 		}
-		curField++
-	}
-
-	if err := file.SaveAs(c.outFilePath); err != nil {
-		c.logger.Error(err.Error(), err)
-		return err
-	}
-
-	return nil
-
-}
-
-func (c *ResourcesCmd) DumpCsv(views []*CfnResourcesView) error {
-	csvViews := []CfnResourcesCsvView{}
-
-	for _, view := range views {
-		var errorString string
-		if view.Error == nil {
-			errorString = ""
-		} else {
-			errorString = view.Error.Error()
+		if c.enrich {
+			enrichResources(cloudcontrolapi.New(sess), c.config.ResourceEnrichments, resources)
 		}
-		if len(view.Resources) == 0 {
-			csvViews = append(csvViews, CfnResourcesCsvView{
-				AccountId:           view.AccountId,
-				AccountName:         view.AccountName,
-				Region:              view.Region,
-				StackName:           view.StackName,
-				ResourcePhysicalId:  "",
-				ResourceLogicalId:   "",
-				ResourceType:        "",
-				ResourceDescription: "",
-				ResourceStatus:      "",
-				ResourceDriftStatus: "",
-				Error:               errorString,
-			})
-		}
-		for _, resource := range view.Resources {
-			csvViews = append(csvViews, CfnResourcesCsvView{
-				AccountId:           view.AccountId,
-				AccountName:         view.AccountName,
-				Region:              view.Region,
-				StackName:           view.StackName,
-				ResourcePhysicalId:  resource.PhysicalId,
-				ResourceLogicalId:   resource.LogicalId,
-				ResourceType:        resource.Type,
-				ResourceDescription: resource.Description,
-				ResourceStatus:      resource.Status,
-				ResourceDriftStatus: resource.DriftStatus,
-				Error:               errorString,
+		templateHash := optionalTemplateFingerprint(c.templates.getOptionalTemplateBody(cfn, *matchedStack.StackId, c.logger))
+		var declaredResourceTypes, deployedResourceTypes []string
+		var summaryError string
+		if c.summary {
+			for _, resource := range stackResources {
+				deployedResourceTypes = append(deployedResourceTypes, aws.StringValue(resource.ResourceType))
+			}
+			templateSummary, summaryErr := cfn.GetTemplateSummary(&cloudformation.GetTemplateSummaryInput{
+				StackName: matchedStack.StackId,
 			})
+			if summaryErr != nil {
+				c.logger.Warn(fmt.Sprintf("failed to get template summary of cfn stack: %s", *matchedStack.StackName), "error", summaryErr)
+				summaryError = summaryErr.Error()
+			} else {
+				declaredResourceTypes = aws.StringValueSlice(templateSummary.ResourceTypes)
+			}
 		}
+		views = append(views, &CfnResourcesView{
+			AccountId:             accountConfig.Id,
+			AccountName:           accountConfig.Name,
+			Region:                region,
+			StackName:             *matchedStack.StackName,
+			StackTags:             newCfnTags(matchedStack.Tags),
+			TemplateHash:          templateHash,
+			StackConsoleUrl:       stackUrl,
+			DeclaredResourceTypes: declaredResourceTypes,
+			DeployedResourceTypes: deployedResourceTypes,
+			SummaryError:          summaryError,
+			Resources:             resources,
+		})
 	}
 
-	var writer *os.File
-	var err error
-	if c.outFilePath != "" {
-		writer, err = os.Create(c.outFilePath)
-		if err != nil {
-			return err
-		}
-		defer writer.Close()
-	} else {
-		writer = os.Stdout
-	}
-
-	err = gocsv.Marshal(csvViews, writer)
-	if err != nil {
-		return err
-	}
-
-	return nil
-
+	return views
 }
 
-func (c *ResourcesCmd) DumpJson(views []*CfnResourcesView) error {
-
-	jsonViews, err := json.Marshal(views)
-	if err != nil {
-		return err
-	}
-
-	var writer *os.File
-	if c.outFilePath != "" {
-		writer, err = os.Create(c.outFilePath)
+// listStackResources returns all resources of the stack, following the pagination of ListStackResources.
+// DescribeStackResources isn't used since it returns at most 100 resources.
+func listStackResources(cfn cloudformationiface.CloudFormationAPI, stackId string) ([]*cloudformation.StackResourceSummary, error) {
+	stackResources := []*cloudformation.StackResourceSummary{}
+	input := &cloudformation.ListStackResourcesInput{StackName: aws.String(stackId)}
+	for {
+		listStackResourcesOutput, err := cfn.ListStackResources(input)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		defer writer.Close()
-	} else {
-		writer = os.Stdout
-	}
-
-	_, err = writer.Write(jsonViews)
-	if err != nil {
-		return err
-	}
-
-	return nil
-
-}
-
-func (c *ResourcesCmd) calcTotalViews() int {
-	total := 0
-	for _, accountConfig := range c.config.AccountConfigs {
-		total += len(accountConfig.Filters.Regions)
-	}
-	return total
-}
-
-func (c *ResourcesCmd) GetGlobalViews() []*CfnResourcesView {
-	globalViews := []*CfnResourcesView{}
-
-	totalViews := c.calcTotalViews()
-	progress := 0
-	channel := make(chan []*CfnResourcesView, totalViews)
-
-	var bar *progressbar.ProgressBar
-	if !c.verbose {
-		bar = progressbar.Default(int64(totalViews))
-	}
-	for ai := range c.config.AccountConfigs {
-		for ri := range c.config.AccountConfigs[ai].Filters.Regions {
-
-			go func(ch chan []*CfnResourcesView, ai, ri int) {
-				views := []*CfnResourcesView{}
-				c.logger.Info(
-					"get cfn views", "accountId", c.config.AccountConfigs[ai].Id, "region", c.config.AccountConfigs[ai].Filters.Regions[ri],
-				)
-				// setup cloudformation client
-				var sess *session.Session
-				if c.config.AccountConfigs[ai].Credential.Type == "CLI" {
-					sess = session.Must(session.NewSessionWithOptions(session.Options{
-						Profile: c.config.AccountConfigs[ai].Credential.ProfileName,
-						Config:  *aws.NewConfig().WithRegion(c.config.AccountConfigs[ai].Filters.Regions[ri]),
-					}))
-				} else {
-					sess = session.Must(session.NewSessionWithOptions(session.Options{
-						Config: *aws.NewConfig().WithRegion(c.config.AccountConfigs[ai].Filters.Regions[ri]),
-					}))
-				}
-
-				cfn := cloudformation.New(sess)
-
-				// describe all stacks at the account and region and filter them
-				var matchedStacks []cloudformation.Stack
-				for {
-					describeStacksOutpus, err := cfn.DescribeStacks(&cloudformation.DescribeStacksInput{})
-					if err != nil {
-						views = append(views, &CfnResourcesView{
-							AccountId:   c.config.AccountConfigs[ai].Id,
-							AccountName: c.config.AccountConfigs[ai].Name,
-							Region:      c.config.AccountConfigs[ai].Filters.Regions[ri],
-							Error:       err,
-						})
-
-						break
-					}
-					for _, stack := range describeStacksOutpus.Stacks {
-
-						matched, _ := regexp.MatchString(c.config.AccountConfigs[ai].Filters.StackNameRegex, *stack.StackName)
-						if matched && c.hasAllTags(stack.Tags, c.config.AccountConfigs[ai].Filters.StackTags) {
-							c.logger.Info(fmt.Sprintf("matched cfn stack: %s", *stack.StackName), "accountId", c.config.AccountConfigs[ai].Id, "region", c.config.AccountConfigs[ai].Filters.Regions[ri])
-							matchedStacks = append(matchedStacks, *stack)
-						}
-					}
-
-					if describeStacksOutpus.NextToken == nil {
-						break
-					}
-				}
-
-				// describe matched stacks' parameters definitions
-				for _, matchedStack := range matchedStacks {
-					stackResources, err := cfn.DescribeStackResources(&cloudformation.DescribeStackResourcesInput{
-						StackName: matchedStack.StackName,
-					})
-					if err != nil {
-						views = append(views, &CfnResourcesView{
-							AccountId:   c.config.AccountConfigs[ai].Id,
-							AccountName: c.config.AccountConfigs[ai].Name,
-							Region:      c.config.AccountConfigs[ai].Filters.Regions[ri],
-							StackName:   *matchedStack.StackName,
-							Error:       err,
-						})
-						break
-					}
-					var resources []CfnResource
-					for _, resource := range stackResources.StackResources {
-						description := ""
-						driftStatus := ""
-						if d := resource.Description; d != nil {
-							description = *d
-						}
-						if d := resource.DriftInformation.StackResourceDriftStatus; d != nil {
-							driftStatus = *d
-						}
-						resources = append(resources, CfnResource{
-							PhysicalId:  *resource.PhysicalResourceId,
-							LogicalId:   *resource.LogicalResourceId,
-							Type:        *resource.ResourceType,
-							Status:      *resource.ResourceStatus,
-							Description: description,
-							DriftStatus: driftStatus,
-						})
-					}
-					views = append(views, &CfnResourcesView{
-						AccountId:   c.config.AccountConfigs[ai].Id,
-						AccountName: c.config.AccountConfigs[ai].Name,
-						Region:      c.config.AccountConfigs[ai].Filters.Regions[ri],
-						StackName:   *matchedStack.StackName,
-						Resources:   resources,
-						Error:       nil,
-					})
-				}
-
-				ch <- views
-
-				if !c.verbose {
-					bar.Add(1)
-				}
-				progress += 1
-				if progress == totalViews {
-					close(ch)
-				}
-			}(channel, ai, ri)
+		stackResources = append(stackResources, listStackResourcesOutput.StackResourceSummaries...)
+		if listStackResourcesOutput.NextToken == nil {
+			return stackResources, nil
 		}
+		input.NextToken = listStackResourcesOutput.NextToken
 	}
-
-	for views := range channel {
-		globalViews = append(globalViews, views...)
-	}
-
-	sort.Slice(globalViews, func(i, j int) bool { return globalViews[i].AccountId < globalViews[j].AccountId })
-	sort.Slice(globalViews, func(i, j int) bool { return globalViews[i].Region < globalViews[j].Region })
-	sort.Slice(globalViews, func(i, j int) bool { return globalViews[i].StackName < globalViews[j].StackName })
-
-	return globalViews
-
 }
 
-func (c *ResourcesCmd) hasAllTags(stackTags []*cloudformation.Tag, filterTags []config.Tag) bool {
-	hasAllTags := []bool{}
-
-	if len(filterTags) == 0 {
-		return true
+// newCfnResource converts the stack resource returned by ListStackResources.
+// resource summaries don't have descriptions, so Description is always empty.
+func newCfnResource(resource *cloudformation.StackResourceSummary) CfnResource {
+	cfnResource := CfnResource{
+		PhysicalId:           aws.StringValue(resource.PhysicalResourceId),
+		LogicalId:            aws.StringValue(resource.LogicalResourceId),
+		Type:                 aws.StringValue(resource.ResourceType),
+		Status:               aws.StringValue(resource.ResourceStatus),
+		StatusReason:         aws.StringValue(resource.ResourceStatusReason),
+		LastUpdatedTimestamp: formatTime(resource.LastUpdatedTimestamp),
 	}
-	for _, filterTag := range filterTags {
-		for _, stackTag := range stackTags {
-			if filterTag.Key == *stackTag.Key && filterTag.Value == *stackTag.Value {
-				hasAllTags = append(hasAllTags, true)
-			}
-		}
+	if m := resource.ModuleInfo; m != nil {
+		cfnResource.ModuleTypeHierarchy = aws.StringValue(m.TypeHierarchy)
+		cfnResource.ModuleLogicalIdHierarchy = aws.StringValue(m.LogicalIdHierarchy)
 	}
-	if len(hasAllTags) == len(filterTags) {
-		return true
-	} else {
-		return false
+	if d := resource.DriftInformation; d != nil {
+		cfnResource.DriftStatus = aws.StringValue(d.StackResourceDriftStatus)
+		cfnResource.DriftLastCheckTimestamp = formatTime(d.LastCheckTimestamp)
 	}
+	return cfnResource
 }
@@ -0,0 +1,129 @@
+package subcommands
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudcontrolapi"
+	"github.com/aws/aws-sdk-go/service/cloudcontrolapi/cloudcontrolapiiface"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+)
+
+// findResourceEnrichment returns the first enrichment whose Type glob matches the resource type, or nil.
+func findResourceEnrichment(resourceEnrichments []config.ResourceEnrichment, resourceType string) *config.ResourceEnrichment {
+	for i := range resourceEnrichments {
+		if matchesAnyGlob(resourceType, []string{resourceEnrichments[i].Type}) {
+			return &resourceEnrichments[i]
+		}
+	}
+	return nil
+}
+
+// enrichResources sets properties of resources whose types are configured to be enriched.
+// failures are recorded per resource so that other resources are still enriched.
+func enrichResources(cc cloudcontrolapiiface.CloudControlApiAPI, resourceEnrichments []config.ResourceEnrichment, resources []CfnResource) {
+	for i := range resources {
+		if findResourceEnrichment(resourceEnrichments, resources[i].Type) == nil || resources[i].PhysicalId == "" {
+			continue
+		}
+		getResourceOutput, err := cc.GetResource(&cloudcontrolapi.GetResourceInput{
+			TypeName:   aws.String(resources[i].Type),
+			Identifier: aws.String(resources[i].PhysicalId),
+		})
+		if err != nil {
+			resources[i].PropertiesError = err.Error()
+			continue
+		}
+		var properties map[string]interface{}
+		if getResourceOutput.ResourceDescription != nil {
+			err = json.Unmarshal([]byte(aws.StringValue(getResourceOutput.ResourceDescription.Properties)), &properties)
+			if err != nil {
+				resources[i].PropertiesError = err.Error()
+				continue
+			}
+		}
+		resources[i].Properties = properties
+	}
+}
+
+// propertyColumns returns property paths of all enrichments in order without duplicates.
+func propertyColumns(resourceEnrichments []config.ResourceEnrichment) []string {
+	columns := []string{}
+	seen := map[string]bool{}
+	for _, resourceEnrichment := range resourceEnrichments {
+		for _, path := range resourceEnrichment.PropertyPaths {
+			if !seen[path] {
+				seen[path] = true
+				columns = append(columns, path)
+			}
+		}
+	}
+	return columns
+}
+
+// propertyValues returns values at the paths configured for the resource type, and empty strings for the others.
+func propertyValues(resourceEnrichments []config.ResourceEnrichment, resource CfnResource, paths []string) []string {
+	values := make([]string, len(paths))
+	resourceEnrichment := findResourceEnrichment(resourceEnrichments, resource.Type)
+	if resourceEnrichment == nil || resource.Properties == nil {
+		return values
+	}
+	for i, path := range paths {
+		for _, configuredPath := range resourceEnrichment.PropertyPaths {
+			if configuredPath != path {
+				continue
+			}
+			if v, ok := lookupPropertyPath(resource.Properties, path); ok {
+				values[i] = propertyValueString(v)
+			}
+		}
+	}
+	return values
+}
+
+// lookupPropertyPath returns the value at the dot separated path such as VersioningConfiguration.Status,
+// where list items are referred by indexes such as Tags.0.Key or Tags[0].Key.
+func lookupPropertyPath(document interface{}, path string) (interface{}, bool) {
+	path = strings.ReplaceAll(strings.ReplaceAll(path, "[", "."), "]", "")
+	current := document
+	for _, key := range strings.Split(path, ".") {
+		if key == "" {
+			continue
+		}
+		switch v := current.(type) {
+		case map[string]interface{}:
+			value, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			current = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func propertyValueString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return compactJson(v)
+	}
+}
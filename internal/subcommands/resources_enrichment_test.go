@@ -0,0 +1,117 @@
+package subcommands
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudcontrolapi"
+	"github.com/aws/aws-sdk-go/service/cloudcontrolapi/cloudcontrolapiiface"
+	cfnConfig "github.com/horietakehiro/cfn-global-views/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCloudControlClient struct {
+	cloudcontrolapiiface.CloudControlApiAPI
+	requested []string
+}
+
+func (m *mockCloudControlClient) GetResource(input *cloudcontrolapi.GetResourceInput) (*cloudcontrolapi.GetResourceOutput, error) {
+	m.requested = append(m.requested, *input.Identifier)
+	if *input.Identifier == "not-found-bucket" {
+		return nil, fmt.Errorf("ResourceNotFoundException")
+	}
+	return &cloudcontrolapi.GetResourceOutput{ResourceDescription: &cloudcontrolapi.ResourceDescription{
+		Identifier: input.Identifier,
+		Properties: aws.String(`{"BucketName":"my-bucket","VersioningConfiguration":{"Status":"Enabled"},"Tags":[{"Key":"ENV","Value":"prod"}],"ObjectLockEnabled":false,"Size":1000000}`),
+	}}, nil
+}
+
+var TEST_RESOURCE_ENRICHMENTS = []cfnConfig.ResourceEnrichment{
+	{Type: "AWS::S3::Bucket", PropertyPaths: []string{"VersioningConfiguration.Status", "Tags[0].Value", "ObjectLockEnabled", "Size"}},
+	{Type: "AWS::SQS::*", PropertyPaths: []string{"VisibilityTimeout", "Tags[0].Value"}},
+}
+
+func TestResourcesEnrichment_enrichResources(t *testing.T) {
+	resources := []CfnResource{
+		{PhysicalId: "my-bucket", Type: "AWS::S3::Bucket"},
+		{PhysicalId: "not-found-bucket", Type: "AWS::S3::Bucket"},
+		{PhysicalId: "my-topic", Type: "AWS::SNS::Topic"},
+	}
+	cc := &mockCloudControlClient{}
+	enrichResources(cc, TEST_RESOURCE_ENRICHMENTS, resources)
+
+	assert.Equal(t, []string{"my-bucket", "not-found-bucket"}, cc.requested)
+	assert.Equal(t, "my-bucket", resources[0].Properties["BucketName"])
+	assert.Equal(t, "", resources[0].PropertiesError)
+	assert.Nil(t, resources[1].Properties)
+	assert.Equal(t, "ResourceNotFoundException", resources[1].PropertiesError)
+	assert.Nil(t, resources[2].Properties)
+}
+
+func TestResourcesEnrichment_propertyColumns(t *testing.T) {
+	assert.Equal(t,
+		[]string{"VersioningConfiguration.Status", "Tags[0].Value", "ObjectLockEnabled", "Size", "VisibilityTimeout"},
+		propertyColumns(TEST_RESOURCE_ENRICHMENTS),
+	)
+}
+
+func TestResourcesEnrichment_propertyValues(t *testing.T) {
+	resources := []CfnResource{{PhysicalId: "my-bucket", Type: "AWS::S3::Bucket"}}
+	enrichResources(&mockCloudControlClient{}, TEST_RESOURCE_ENRICHMENTS, resources)
+	paths := propertyColumns(TEST_RESOURCE_ENRICHMENTS)
+
+	assert.Equal(t, []string{"Enabled", "prod", "false", "1000000", ""}, propertyValues(TEST_RESOURCE_ENRICHMENTS, resources[0], paths))
+	assert.Equal(t, []string{"", "", "", "", ""}, propertyValues(TEST_RESOURCE_ENRICHMENTS, CfnResource{Type: "AWS::SNS::Topic"}, paths))
+}
+
+func TestResourcesEnrichment_lookupPropertyPath(t *testing.T) {
+	document := map[string]interface{}{
+		"VersioningConfiguration": map[string]interface{}{"Status": "Enabled"},
+		"Tags":                    []interface{}{map[string]interface{}{"Key": "ENV", "Value": "prod"}},
+	}
+	for _, c := range []struct {
+		path  string
+		value interface{}
+		ok    bool
+	}{
+		{"VersioningConfiguration.Status", "Enabled", true},
+		{"Tags.0.Value", "prod", true},
+		{"Tags[0].Key", "ENV", true},
+		{"Tags[1].Key", nil, false},
+		{"Tags.Key", nil, false},
+		{"NotExist", nil, false},
+		{"VersioningConfiguration.Status.Value", nil, false},
+	} {
+		value, ok := lookupPropertyPath(document, c.path)
+		assert.Equal(t, c.ok, ok, c.path)
+		assert.Equal(t, c.value, value, c.path)
+	}
+	assert.Equal(t, `{"Key":"ENV","Value":"prod"}`, propertyValueString(document["Tags"].([]interface{})[0]))
+}
+
+func TestResources_toTable_enrich(t *testing.T) {
+	cmd := ResourcesCmd{
+		enrich: true,
+		config: &cfnConfig.CfnGlobalViewsConfig{ResourceEnrichments: TEST_RESOURCE_ENRICHMENTS},
+	}
+	resources := []CfnResource{{PhysicalId: "my-bucket", Type: "AWS::S3::Bucket", DriftStatus: "IN_SYNC"}}
+	enrichResources(&mockCloudControlClient{}, TEST_RESOURCE_ENRICHMENTS, resources)
+	table := cmd.toTable([]*CfnResourcesView{
+		{AccountId: "123456789012", Region: "ap-northeast-1", StackName: "stack", Resources: resources},
+		{AccountId: "123456789012", Region: "ap-northeast-1", StackName: "empty"},
+	})
+
+	at := 0
+	for i, name := range table.header {
+		if name == "ResourceDriftLastCheckTimestamp" {
+			at = i
+		}
+	}
+	assert.Equal(t, "Property:VersioningConfiguration.Status", table.header[at+1])
+	assert.Equal(t, "ResourcePropertiesError", table.header[at+6])
+	// enrichment columns follow all drift columns
+	assert.Equal(t, "StackTemplateHash", table.header[at+7])
+	assert.Equal(t, "Enabled", table.records[0][at+1])
+	assert.Equal(t, "", table.records[1][at+1])
+}
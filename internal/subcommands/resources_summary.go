@@ -0,0 +1,142 @@
+package subcommands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type CfnResourceTypeSummaryRow struct {
+	ResourceType string
+	// Counts are numbers of resources keyed by columns
+	Counts map[string]int
+	Total  int
+	// DeployedStackCount is the number of stacks having resources of the type
+	DeployedStackCount int
+	// DeclaredStackCount is the number of stacks whose templates declare the type
+	DeclaredStackCount int
+	// UndeployedStacks are stacks whose templates declare the type but which have no resources of it,
+	// e.g. resources skipped by conditions
+	UndeployedStacks []string
+}
+
+type CfnResourceTypeSummary struct {
+	Columns []string
+	Rows    []CfnResourceTypeSummaryRow
+	// Totals are numbers of resources of all types keyed by columns
+	Totals map[string]int
+	Total  int
+	// UnknownStacks are stacks whose template summaries failed to be got.
+	// their declared resource types are unknown, so they are not counted as declaring or lacking any types
+	UnknownStacks []string
+}
+
+// GetSummary counts resources per resource type and account and region, and compares
+// deployed resource types with resource types declared in the templates of each stack.
+// resources filtered out by resource filters are still deployed, so they don't make their types undeployed.
+func (c *ResourcesCmd) GetSummary(views []*CfnResourcesView) *CfnResourceTypeSummary {
+	summary := &CfnResourceTypeSummary{Columns: []string{}, Rows: []CfnResourceTypeSummaryRow{}, Totals: map[string]int{}, UnknownStacks: []string{}}
+
+	columns := map[string]bool{}
+	rowIndices := map[string]int{}
+	rowOf := func(resourceType string) *CfnResourceTypeSummaryRow {
+		i, ok := rowIndices[resourceType]
+		if !ok {
+			i = len(summary.Rows)
+			rowIndices[resourceType] = i
+			summary.Rows = append(summary.Rows, CfnResourceTypeSummaryRow{
+				ResourceType:     resourceType,
+				Counts:           map[string]int{},
+				UndeployedStacks: []string{},
+			})
+		}
+		return &summary.Rows[i]
+	}
+
+	for _, view := range views {
+		if view.StackName == "" {
+			continue
+		}
+		accountLabel := view.AccountName
+		if accountLabel == "" {
+			accountLabel = view.AccountId
+		}
+		column := fmt.Sprintf("%s/%s", accountLabel, view.Region)
+		columns[column] = true
+
+		deployed := map[string]bool{}
+		for _, resource := range view.Resources {
+			row := rowOf(resource.Type)
+			row.Counts[column]++
+			row.Total++
+			summary.Totals[column]++
+			summary.Total++
+			if !deployed[resource.Type] {
+				deployed[resource.Type] = true
+				row.DeployedStackCount++
+			}
+		}
+		if view.SummaryError != "" {
+			summary.UnknownStacks = append(summary.UnknownStacks, fmt.Sprintf("%s/%s/%s", view.AccountId, view.Region, view.StackName))
+		}
+		unfiltered := map[string]bool{}
+		for _, resourceType := range view.DeployedResourceTypes {
+			unfiltered[resourceType] = true
+		}
+		for _, resourceType := range view.DeclaredResourceTypes {
+			if !matchesAnyGlob(resourceType, c.resourceType) {
+				continue
+			}
+			row := rowOf(resourceType)
+			row.DeclaredStackCount++
+			if !deployed[resourceType] && !unfiltered[resourceType] {
+				row.UndeployedStacks = append(row.UndeployedStacks, fmt.Sprintf("%s/%s/%s", view.AccountId, view.Region, view.StackName))
+			}
+		}
+	}
+
+	for column := range columns {
+		summary.Columns = append(summary.Columns, column)
+	}
+	sort.Strings(summary.Columns)
+	sort.SliceStable(summary.Rows, func(i, j int) bool {
+		return summary.Rows[i].ResourceType < summary.Rows[j].ResourceType
+	})
+
+	return summary
+}
+
+// toTable converts the summary into a table with a total row at the bottom,
+// followed by a row of stacks whose declared resource types are unknown if any.
+// cells of stacks lacking declared resource types are highlighted.
+func (s *CfnResourceTypeSummary) toTable() *table {
+	t := &table{header: append(append([]string{"ResourceType"}, s.Columns...), "Total", "DeployedStackCount", "DeclaredStackCount", "UndeployedStacks")}
+	for r, row := range s.Rows {
+		record := []interface{}{row.ResourceType}
+		for _, column := range s.Columns {
+			record = append(record, row.Counts[column])
+		}
+		record = append(record, row.Total, row.DeployedStackCount, row.DeclaredStackCount, strings.Join(row.UndeployedStacks, ","))
+		if len(row.UndeployedStacks) != 0 {
+			t.highlight(r, len(record)-1)
+		}
+		t.records = append(t.records, record)
+	}
+
+	record := []interface{}{"Total"}
+	for _, column := range s.Columns {
+		record = append(record, s.Totals[column])
+	}
+	t.records = append(t.records, append(record, s.Total, "", "", ""))
+
+	if len(s.UnknownStacks) != 0 {
+		record := []interface{}{"UnknownDeclaredResourceTypes"}
+		for range s.Columns {
+			record = append(record, "")
+		}
+		record = append(record, "", "", "", strings.Join(s.UnknownStacks, ","))
+		t.highlight(len(t.records), len(record)-1)
+		t.records = append(t.records, record)
+	}
+	return t
+}
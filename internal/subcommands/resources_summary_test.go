@@ -0,0 +1,123 @@
+package subcommands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var TEST_RESOURCES_VIEWS = []*CfnResourcesView{
+	{
+		AccountId: "111111111111", AccountName: "main-account", Region: "ap-northeast-1", StackName: "app",
+		DeclaredResourceTypes: []string{"AWS::Lambda::Function", "AWS::S3::Bucket", "AWS::SQS::Queue"},
+		Resources: []CfnResource{
+			{Type: "AWS::Lambda::Function"}, {Type: "AWS::Lambda::Function"}, {Type: "AWS::S3::Bucket"},
+		},
+	},
+	{
+		AccountId: "222222222222", Region: "ap-northeast-1", StackName: "app",
+		DeclaredResourceTypes: []string{"AWS::Lambda::Function", "AWS::S3::Bucket", "AWS::SQS::Queue"},
+		Resources: []CfnResource{
+			{Type: "AWS::Lambda::Function"}, {Type: "AWS::SQS::Queue"},
+		},
+	},
+	{AccountId: "222222222222", Region: "ap-northeast-3"},
+}
+
+func TestResources_GetSummary(t *testing.T) {
+	cmd := ResourcesCmd{}
+	summary := cmd.GetSummary(TEST_RESOURCES_VIEWS)
+
+	assert.Equal(t, []string{"222222222222/ap-northeast-1", "main-account/ap-northeast-1"}, summary.Columns)
+	assert.Equal(t, 3, len(summary.Rows))
+
+	lambda := summary.Rows[0]
+	assert.Equal(t, "AWS::Lambda::Function", lambda.ResourceType)
+	assert.Equal(t, map[string]int{"222222222222/ap-northeast-1": 1, "main-account/ap-northeast-1": 2}, lambda.Counts)
+	assert.Equal(t, 3, lambda.Total)
+	assert.Equal(t, 2, lambda.DeployedStackCount)
+	assert.Equal(t, 2, lambda.DeclaredStackCount)
+	assert.Equal(t, []string{}, lambda.UndeployedStacks)
+
+	bucket := summary.Rows[1]
+	assert.Equal(t, "AWS::S3::Bucket", bucket.ResourceType)
+	assert.Equal(t, 1, bucket.DeployedStackCount)
+	assert.Equal(t, []string{"222222222222/ap-northeast-1/app"}, bucket.UndeployedStacks)
+
+	queue := summary.Rows[2]
+	assert.Equal(t, []string{"111111111111/ap-northeast-1/app"}, queue.UndeployedStacks)
+
+	assert.Equal(t, map[string]int{"222222222222/ap-northeast-1": 2, "main-account/ap-northeast-1": 3}, summary.Totals)
+	assert.Equal(t, 5, summary.Total)
+}
+
+func TestResources_GetSummary_resourceType(t *testing.T) {
+	cmd := ResourcesCmd{resourceType: []string{"AWS::S3::*"}}
+	views := []*CfnResourcesView{{
+		AccountId: "111111111111", Region: "ap-northeast-1", StackName: "app",
+		DeclaredResourceTypes: []string{"AWS::Lambda::Function", "AWS::S3::Bucket"},
+	}}
+	summary := cmd.GetSummary(views)
+
+	assert.Equal(t, 1, len(summary.Rows))
+	assert.Equal(t, "AWS::S3::Bucket", summary.Rows[0].ResourceType)
+	assert.Equal(t, 1, summary.Rows[0].DeclaredStackCount)
+	assert.Equal(t, 0, summary.Total)
+}
+
+func TestResources_GetSummary_resourceStatus(t *testing.T) {
+	cmd := ResourcesCmd{resourceStatus: []string{"*_FAILED"}}
+	views := []*CfnResourcesView{{
+		AccountId: "111111111111", Region: "ap-northeast-1", StackName: "app",
+		DeclaredResourceTypes: []string{"AWS::Lambda::Function", "AWS::S3::Bucket", "AWS::SQS::Queue"},
+		DeployedResourceTypes: []string{"AWS::Lambda::Function", "AWS::S3::Bucket"},
+		Resources:             []CfnResource{{Type: "AWS::Lambda::Function", Status: "UPDATE_FAILED"}},
+	}}
+	summary := cmd.GetSummary(views)
+
+	// resources filtered out by statuses are still deployed
+	assert.Equal(t, 3, len(summary.Rows))
+	assert.Equal(t, 1, summary.Rows[0].Total)
+	assert.Equal(t, []string{}, summary.Rows[0].UndeployedStacks)
+	assert.Equal(t, "AWS::S3::Bucket", summary.Rows[1].ResourceType)
+	assert.Equal(t, 0, summary.Rows[1].Total)
+	assert.Equal(t, []string{}, summary.Rows[1].UndeployedStacks)
+	assert.Equal(t, []string{"111111111111/ap-northeast-1/app"}, summary.Rows[2].UndeployedStacks)
+}
+
+func TestResources_summary_toTable(t *testing.T) {
+	cmd := ResourcesCmd{}
+	table := cmd.GetSummary(TEST_RESOURCES_VIEWS).toTable()
+
+	assert.Equal(t, []string{
+		"ResourceType", "222222222222/ap-northeast-1", "main-account/ap-northeast-1",
+		"Total", "DeployedStackCount", "DeclaredStackCount", "UndeployedStacks",
+	}, table.header)
+	assert.Equal(t, 4, len(table.records))
+	assert.Equal(t, []interface{}{"AWS::Lambda::Function", 1, 2, 3, 2, 2, ""}, table.records[0])
+	assert.Equal(t, []interface{}{"Total", 2, 3, 5, "", "", ""}, table.records[3])
+	assert.True(t, table.highlights[[2]int{1, 6}])
+	assert.False(t, table.highlights[[2]int{0, 6}])
+}
+
+func TestResources_GetSummary_unknown(t *testing.T) {
+	views := append([]*CfnResourcesView{}, TEST_RESOURCES_VIEWS...)
+	views = append(views, &CfnResourcesView{
+		AccountId: "333333333333", Region: "ap-northeast-1", StackName: "app",
+		SummaryError: "AccessDenied",
+		Resources:    []CfnResource{{Type: "AWS::S3::Bucket"}},
+	})
+
+	cmd := ResourcesCmd{}
+	summary := cmd.GetSummary(views)
+	assert.Equal(t, []string{"333333333333/ap-northeast-1/app"}, summary.UnknownStacks)
+	// the stack isn't counted as declaring the type
+	assert.Equal(t, "AWS::S3::Bucket", summary.Rows[1].ResourceType)
+	assert.Equal(t, 2, summary.Rows[1].DeployedStackCount)
+	assert.Equal(t, 2, summary.Rows[1].DeclaredStackCount)
+
+	table := summary.toTable()
+	assert.Equal(t, 5, len(table.records))
+	assert.Equal(t, []interface{}{"UnknownDeclaredResourceTypes", "", "", "", "", "", "", "333333333333/ap-northeast-1/app"}, table.records[4])
+	assert.True(t, table.highlights[[2]int{4, 7}])
+}
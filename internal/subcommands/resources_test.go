@@ -1,9 +1,11 @@
 package subcommands
 
 import (
-	"os"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/gookit/config/v2"
 	cfnConfig "github.com/horietakehiro/cfn-global-views/config"
 	"github.com/stretchr/testify/assert"
@@ -45,25 +47,7 @@ func TestResources_valid(t *testing.T) {
 }
 
 func TestResources_invalid(t *testing.T) {
-	defer config.ClearAll()
-	defer func() { os.Remove(TMP_CONFIG_PATH) }()
-
-	tmpConfigYaml := `
-RootConfig:
-  Credential:
-    Type: "CLI"
-    ProfileName: not-exist-profile
-  Filters:
-    Regions:
-      - "not-exist-region"
-AccountConfigs:
-  - Name: main-account
-    Id: 123456789012
-`
-	writeTmpYaml(tmpConfigYaml)
-
-	c, err := cfnConfig.GetConfig(TMP_CONFIG_PATH)
-	assert.Nil(t, err)
+	c := getInvalidConfig(t, "")
 
 	cmd := ResourcesCmd{
 		config: c,
@@ -75,3 +59,52 @@ AccountConfigs:
 	assert.NotNil(t, views[0].Error, views[0])
 
 }
+
+func TestResources_newCfnResource(t *testing.T) {
+	timestamp := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	resource := newCfnResource(&cloudformation.StackResourceSummary{
+		PhysicalResourceId:   aws.String("my-function"),
+		LogicalResourceId:    aws.String("ModuleFunction"),
+		ResourceType:         aws.String("AWS::Lambda::Function"),
+		ResourceStatus:       aws.String("UPDATE_FAILED"),
+		ResourceStatusReason: aws.String("Resource handler returned message: \"Invalid request\""),
+		LastUpdatedTimestamp: aws.Time(timestamp),
+		ModuleInfo: &cloudformation.ModuleInfo{
+			TypeHierarchy:      aws.String("My::Lambda::Function::MODULE"),
+			LogicalIdHierarchy: aws.String("Module"),
+		},
+		DriftInformation: &cloudformation.StackResourceDriftInformationSummary{
+			StackResourceDriftStatus: aws.String("IN_SYNC"),
+			LastCheckTimestamp:       aws.Time(timestamp),
+		},
+	})
+
+	assert.Equal(t, "UPDATE_FAILED", resource.Status)
+	assert.Equal(t, "Resource handler returned message: \"Invalid request\"", resource.StatusReason)
+	assert.Equal(t, "2023-04-01T12:00:00Z", resource.LastUpdatedTimestamp)
+	assert.Equal(t, "My::Lambda::Function::MODULE", resource.ModuleTypeHierarchy)
+	assert.Equal(t, "Module", resource.ModuleLogicalIdHierarchy)
+	assert.Equal(t, "IN_SYNC", resource.DriftStatus)
+	assert.Equal(t, "2023-04-01T12:00:00Z", resource.DriftLastCheckTimestamp)
+
+	resource = newCfnResource(&cloudformation.StackResourceSummary{
+		LogicalResourceId: aws.String("Bucket"),
+		ResourceType:      aws.String("AWS::S3::Bucket"),
+		ResourceStatus:    aws.String("CREATE_IN_PROGRESS"),
+	})
+	assert.Equal(t, "", resource.PhysicalId)
+	assert.Equal(t, "", resource.ModuleTypeHierarchy)
+	assert.Equal(t, "", resource.DriftLastCheckTimestamp)
+}
+
+func TestResources_listStackResources(t *testing.T) {
+	// resources over pages are all listed
+	stackResources, err := listStackResources(&mockUnmanagedCfnClient{}, "app-id")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(stackResources))
+	assert.Equal(t, "app-bucket", *stackResources[0].PhysicalResourceId)
+	assert.Equal(t, "Queue", *stackResources[2].LogicalResourceId)
+
+	_, err = listStackResources(&mockUnmanagedCfnClient{failedStackId: "app-id"}, "app-id")
+	assert.EqualError(t, err, "AccessDenied")
+}
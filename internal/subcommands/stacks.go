@@ -0,0 +1,297 @@
+package subcommands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/google/subcommands"
+	"golang.org/x/exp/slog"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+type CfnTag struct {
+	Key   string
+	Value string
+}
+
+type CfnRollbackConfiguration struct {
+	MonitoringTimeInMinutes int64
+	RollbackTriggerArns     []string
+}
+
+type CfnStack struct {
+	StackId               string
+	Status                string
+	StatusReason          string
+	CreationTime          string
+	LastUpdatedTime       string
+	Description           string
+	TerminationProtection bool
+	RoleArn               string
+	Capabilities          []string
+	NotificationArns      []string
+	RollbackConfiguration CfnRollbackConfiguration
+	DriftStatus           string
+	LastDriftCheckTime    string
+	ParentId              string
+	RootId                string
+	// TemplateHash is the fingerprint of the normalized original template
+	TemplateHash string
+	Tags         []CfnTag
+	// ConsoleUrl is set only if links are requested
+	ConsoleUrl string `json:",omitempty"`
+}
+
+type CfnStacksView struct {
+	AccountId   string
+	AccountName string
+	Region      string
+	StackName   string
+	Stack       CfnStack
+	Error       error
+}
+
+type CfnStacksCsvView struct {
+	AccountId                   string
+	AccountName                 string
+	Region                      string
+	StackName                   string
+	StackId                     string
+	StackStatus                 string
+	StackStatusReason           string
+	StackCreationTime           string
+	StackLastUpdatedTime        string
+	StackDescription            string
+	StackTerminationProtection  string
+	StackRoleArn                string
+	StackCapabilities           string
+	StackNotificationArns       string
+	StackRollbackMonitoringTime string
+	StackRollbackTriggerArns    string
+	StackDriftStatus            string
+	StackLastDriftCheckTime     string
+	StackParentId               string
+	StackRootId                 string
+	StackTemplateHash           string
+	StackTags                   string
+	Error                       string
+}
+
+type StacksCmd struct {
+	subcommands.Command
+	configFilePath string
+	outFilePath    string
+	format         string
+	verbose        bool
+	links          bool
+	logger         *slog.Logger
+	config         *config.CfnGlobalViewsConfig
+	templates      *templateCache
+	collected      func(views []*CfnStacksView)
+}
+
+func (*StacksCmd) Name() string {
+	return "stacks"
+}
+func (*StacksCmd) Synopsis() string {
+	return "list cfn stacks"
+}
+func (*StacksCmd) Usage() string {
+	return "stacks -c path/to/config.yaml [-links]"
+}
+func (c *StacksCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
+	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
+	f.StringVar(&c.format, "f", "csv", formatUsage("csv"))
+	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+	f.BoolVar(&c.links, "links", false, "if set, add console urls of stacks. excel output shows them as hyperlinks")
+}
+
+func (c *StacksCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return subcommands.ExitFailure
+	}
+
+	return exitStatus(c.logger, writeGlobalViews(c.format, c.outFilePath, &c.collected, c.GetGlobalViews, c.Report))
+}
+
+func (c *StacksCmd) toCsvViews(views []*CfnStacksView) []CfnStacksCsvView {
+	csvViews := []CfnStacksCsvView{}
+
+	for _, view := range views {
+		stack := view.Stack
+		rollbackMonitoringTime := ""
+		if stack.RollbackConfiguration.MonitoringTimeInMinutes != 0 {
+			rollbackMonitoringTime = strconv.FormatInt(stack.RollbackConfiguration.MonitoringTimeInMinutes, 10)
+		}
+		terminationProtection := ""
+		if view.StackName != "" {
+			terminationProtection = strconv.FormatBool(stack.TerminationProtection)
+		}
+		csvViews = append(csvViews, CfnStacksCsvView{
+			AccountId:                   view.AccountId,
+			AccountName:                 view.AccountName,
+			Region:                      view.Region,
+			StackName:                   view.StackName,
+			StackId:                     stack.StackId,
+			StackStatus:                 stack.Status,
+			StackStatusReason:           stack.StatusReason,
+			StackCreationTime:           stack.CreationTime,
+			StackLastUpdatedTime:        stack.LastUpdatedTime,
+			StackDescription:            stack.Description,
+			StackTerminationProtection:  terminationProtection,
+			StackRoleArn:                stack.RoleArn,
+			StackCapabilities:           strings.Join(stack.Capabilities, ","),
+			StackNotificationArns:       strings.Join(stack.NotificationArns, ","),
+			StackRollbackMonitoringTime: rollbackMonitoringTime,
+			StackRollbackTriggerArns:    strings.Join(stack.RollbackConfiguration.RollbackTriggerArns, ","),
+			StackDriftStatus:            stack.DriftStatus,
+			StackLastDriftCheckTime:     stack.LastDriftCheckTime,
+			StackParentId:               stack.ParentId,
+			StackRootId:                 stack.RootId,
+			StackTemplateHash:           stack.TemplateHash,
+			StackTags:                   formatTags(stack.Tags),
+			Error:                       errorString(view.Error),
+		})
+	}
+
+	return csvViews
+}
+
+func (c *StacksCmd) toTable(views []*CfnStacksView) *table {
+	t := newTable(c.toCsvViews(views))
+	if c.links {
+		values := [][]string{}
+		for _, view := range views {
+			values = append(values, []string{view.Stack.ConsoleUrl})
+		}
+		t.insertColumns("StackId", []string{"StackConsoleUrl"}, values)
+		t.link("StackConsoleUrl")
+	}
+	return t
+}
+
+func (c *StacksCmd) Report(views []*CfnStacksView) *output.Report {
+	return &output.Report{Tables: []*output.Table{c.toTable(views).toOutput(c.Name(), views)}}
+}
+
+func (c *StacksCmd) GetGlobalViews() []*CfnStacksView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, c.collected)
+
+	sortViews(globalViews, func(view *CfnStacksView) []string {
+		return []string{view.AccountId, view.Region, view.StackName}
+	})
+
+	return globalViews
+}
+
+func (c *StacksCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnStacksView {
+	views := []*CfnStacksView{}
+	c.logger.Info("get cfn views", "accountId", accountConfig.Id, "region", region)
+
+	cfn := cloudformation.New(newSession(accountConfig, region))
+
+	matchedStacks, err := describeMatchedStacks(cfn, accountConfig.Filters)
+	if err != nil {
+		return append(views, &CfnStacksView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Error:       err,
+		})
+	}
+
+	for _, matchedStack := range matchedStacks {
+		c.logger.Info(fmt.Sprintf("matched cfn stack: %s", *matchedStack.StackName), "accountId", accountConfig.Id, "region", region)
+		stack := newCfnStack(matchedStack)
+		stack.TemplateHash = optionalTemplateFingerprint(c.templates.getOptionalTemplateBody(cfn, *matchedStack.StackId, c.logger))
+		if c.links {
+			stack.ConsoleUrl = stackConsoleUrl(region, stack.StackId)
+		}
+		views = append(views, &CfnStacksView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			StackName:   *matchedStack.StackName,
+			Stack:       stack,
+		})
+	}
+
+	return views
+}
+
+func newCfnStack(stack *cloudformation.Stack) CfnStack {
+	cfnStack := CfnStack{
+		StackId:               aws.StringValue(stack.StackId),
+		Status:                aws.StringValue(stack.StackStatus),
+		StatusReason:          aws.StringValue(stack.StackStatusReason),
+		CreationTime:          formatTime(stack.CreationTime),
+		LastUpdatedTime:       formatTime(stack.LastUpdatedTime),
+		Description:           aws.StringValue(stack.Description),
+		TerminationProtection: aws.BoolValue(stack.EnableTerminationProtection),
+		RoleArn:               aws.StringValue(stack.RoleARN),
+		Capabilities:          aws.StringValueSlice(stack.Capabilities),
+		NotificationArns:      aws.StringValueSlice(stack.NotificationARNs),
+		ParentId:              aws.StringValue(stack.ParentId),
+		RootId:                aws.StringValue(stack.RootId),
+		Tags:                  newCfnTags(stack.Tags),
+	}
+	if r := stack.RollbackConfiguration; r != nil {
+		cfnStack.RollbackConfiguration.MonitoringTimeInMinutes = aws.Int64Value(r.MonitoringTimeInMinutes)
+		for _, trigger := range r.RollbackTriggers {
+			cfnStack.RollbackConfiguration.RollbackTriggerArns = append(cfnStack.RollbackConfiguration.RollbackTriggerArns, aws.StringValue(trigger.Arn))
+		}
+	}
+	if d := stack.DriftInformation; d != nil {
+		cfnStack.DriftStatus = aws.StringValue(d.StackDriftStatus)
+		cfnStack.LastDriftCheckTime = formatTime(d.LastCheckTimestamp)
+	}
+	return cfnStack
+}
+
+func newCfnTags(tags []*cloudformation.Tag) []CfnTag {
+	cfnTags := []CfnTag{}
+	for _, tag := range tags {
+		cfnTags = append(cfnTags, CfnTag{
+			Key:   aws.StringValue(tag.Key),
+			Value: aws.StringValue(tag.Value),
+		})
+	}
+	return cfnTags
+}
+
+// formatTags formats tags as "Key1=Value1,Key2=Value2" for csv and excel outputs.
+func formatTags(tags []CfnTag) string {
+	pairs := []string{}
+	for _, tag := range tags {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", tag.Key, tag.Value))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// tagValues returns values of the tags for each of the keys. missing tags are returned as empty strings.
+func tagValues(tags []CfnTag, keys []string) []string {
+	values := []string{}
+	for _, key := range keys {
+		value := ""
+		for _, tag := range tags {
+			if tag.Key == key {
+				value = tag.Value
+				break
+			}
+		}
+		values = append(values, value)
+	}
+	return values
+}
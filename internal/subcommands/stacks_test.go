@@ -0,0 +1,95 @@
+package subcommands
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gookit/config/v2"
+	cfnConfig "github.com/horietakehiro/cfn-global-views/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStacks_valid(t *testing.T) {
+	defer config.ClearAll()
+
+	configPath := "../../config/test_config.yaml"
+
+	c, err := cfnConfig.GetConfig(configPath)
+	assert.Nil(t, err)
+
+	cmd := StacksCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 4, len(views))
+	numTokyo := 0
+	numOsaka := 0
+	for _, v := range views {
+		assert.NotEqual(t, "", v.Stack.StackId)
+		assert.NotEqual(t, "", v.Stack.Status)
+		assert.NotEqual(t, 0, len(v.Stack.Tags))
+		if v.AccountName == "sub-account" {
+			assert.NotEqual(t, "ap-northeast-3", v.Region)
+			assert.Contains(t, v.StackName, "StackSet")
+		}
+		if v.Region == "ap-northeast-1" {
+			numTokyo += 1
+		} else if v.Region == "ap-northeast-3" {
+			numOsaka += 1
+		}
+
+		assert.Nil(t, v.Error)
+	}
+	assert.Equal(t, 3, numTokyo)
+	assert.Equal(t, 1, numOsaka)
+}
+
+func TestStacks_invalid(t *testing.T) {
+	c := getInvalidConfig(t, "")
+
+	cmd := StacksCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 1, len(views))
+	assert.NotNil(t, views[0].Error, views[0])
+
+	csvViews := cmd.toCsvViews(views)
+	assert.Equal(t, 1, len(csvViews))
+	assert.Equal(t, "", csvViews[0].StackTerminationProtection)
+	assert.NotEqual(t, "", csvViews[0].Error)
+}
+
+func TestStacks_csv_views(t *testing.T) {
+	cmd := StacksCmd{}
+
+	views := []*CfnStacksView{
+		{
+			AccountId: "123456789012",
+			StackName: "stack",
+			Stack: CfnStack{
+				Capabilities: []string{"CAPABILITY_IAM", "CAPABILITY_NAMED_IAM"},
+				RollbackConfiguration: CfnRollbackConfiguration{
+					MonitoringTimeInMinutes: 10,
+				},
+				Tags: []CfnTag{{Key: "ENV", Value: "test"}, {Key: "APP", Value: "cfn-global-views"}},
+			},
+		},
+		{
+			AccountId: "123456789012",
+			Error:     fmt.Errorf("error"),
+		},
+	}
+
+	csvViews := cmd.toCsvViews(views)
+	assert.Equal(t, 2, len(csvViews))
+	assert.Equal(t, "false", csvViews[0].StackTerminationProtection)
+	assert.Equal(t, "CAPABILITY_IAM,CAPABILITY_NAMED_IAM", csvViews[0].StackCapabilities)
+	assert.Equal(t, "10", csvViews[0].StackRollbackMonitoringTime)
+	assert.Equal(t, "ENV=test,APP=cfn-global-views", csvViews[0].StackTags)
+	assert.Equal(t, "error", csvViews[1].Error)
+}
@@ -0,0 +1,283 @@
+package subcommands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/google/subcommands"
+	"golang.org/x/exp/slog"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+type CfnStackSetOperationResult struct {
+	Account      string
+	Region       string
+	Status       string
+	StatusReason string
+}
+
+type CfnStackSetOperation struct {
+	OperationId  string
+	Action       string
+	Status       string
+	StatusReason string
+	CreationTime string
+	EndTime      string
+	Duration     string
+	Results      []CfnStackSetOperationResult
+}
+
+type CfnStackSetOperationsView struct {
+	AccountId    string
+	AccountName  string
+	Region       string
+	StackSetName string
+	Operations   []CfnStackSetOperation
+	Error        error
+}
+
+type CfnStackSetOperationsCsvView struct {
+	AccountId             string
+	AccountName           string
+	Region                string
+	StackSetName          string
+	OperationId           string
+	OperationAction       string
+	OperationStatus       string
+	OperationStatusReason string
+	OperationCreationTime string
+	OperationEndTime      string
+	OperationDuration     string
+	ResultAccount         string
+	ResultRegion          string
+	ResultStatus          string
+	ResultStatusReason    string
+	Error                 string
+}
+
+type StackSetOperationsCmd struct {
+	subcommands.Command
+	configFilePath string
+	outFilePath    string
+	format         string
+	verbose        bool
+	since          string
+	failuresOnly   bool
+	sinceTime      time.Time
+	logger         *slog.Logger
+	config         *config.CfnGlobalViewsConfig
+	collected      func(views []*CfnStackSetOperationsView)
+}
+
+func (*StackSetOperationsCmd) Name() string {
+	return "stackset-operations"
+}
+func (*StackSetOperationsCmd) Synopsis() string {
+	return "list cfn stackset operations and their failed stack instances"
+}
+func (*StackSetOperationsCmd) Usage() string {
+	return "stackset-operations -c path/to/config.yaml [-since 24h] [-failures-only]"
+}
+func (c *StackSetOperationsCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
+	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
+	f.StringVar(&c.format, "f", "csv", formatUsage("csv"))
+	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+	f.StringVar(&c.since, "since", "", "only list operations created within this time window e.g. 30m, 2h, 7d (default is all operations)")
+	f.BoolVar(&c.failuresOnly, "failures-only", false, "if set, only list failed or stopped operations and their failed stack instances")
+}
+
+func (c *StackSetOperationsCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, c.validate)
+	if err != nil {
+		fmt.Println(err.Error())
+		return subcommands.ExitFailure
+	}
+
+	return exitStatus(c.logger, writeGlobalViews(c.format, c.outFilePath, &c.collected, c.GetGlobalViews, c.Report))
+}
+
+// validate checks '-since' arg.
+func (c *StackSetOperationsCmd) validate() error {
+	var err error
+	c.sinceTime, err = parseSince(c.since)
+	return err
+}
+
+func (c *StackSetOperationsCmd) toCsvViews(views []*CfnStackSetOperationsView) []CfnStackSetOperationsCsvView {
+	csvViews := []CfnStackSetOperationsCsvView{}
+
+	for _, view := range views {
+		base := CfnStackSetOperationsCsvView{
+			AccountId:    view.AccountId,
+			AccountName:  view.AccountName,
+			Region:       view.Region,
+			StackSetName: view.StackSetName,
+			Error:        errorString(view.Error),
+		}
+		if len(view.Operations) == 0 {
+			csvViews = append(csvViews, base)
+		}
+		for _, operation := range view.Operations {
+			row := base
+			row.OperationId = operation.OperationId
+			row.OperationAction = operation.Action
+			row.OperationStatus = operation.Status
+			row.OperationStatusReason = operation.StatusReason
+			row.OperationCreationTime = operation.CreationTime
+			row.OperationEndTime = operation.EndTime
+			row.OperationDuration = operation.Duration
+			if len(operation.Results) == 0 {
+				csvViews = append(csvViews, row)
+			}
+			for _, result := range operation.Results {
+				row.ResultAccount = result.Account
+				row.ResultRegion = result.Region
+				row.ResultStatus = result.Status
+				row.ResultStatusReason = result.StatusReason
+				csvViews = append(csvViews, row)
+			}
+		}
+	}
+
+	return csvViews
+}
+
+func (c *StackSetOperationsCmd) Report(views []*CfnStackSetOperationsView) *output.Report {
+	return &output.Report{Tables: []*output.Table{newTable(c.toCsvViews(views)).toOutput(c.Name(), views)}}
+}
+
+func (c *StackSetOperationsCmd) GetGlobalViews() []*CfnStackSetOperationsView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, c.collected)
+
+	sortViews(globalViews, func(view *CfnStackSetOperationsView) []string {
+		return []string{view.AccountId, view.Region, view.StackSetName}
+	})
+
+	return globalViews
+}
+
+func (c *StackSetOperationsCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnStackSetOperationsView {
+	views := []*CfnStackSetOperationsView{}
+	c.logger.Info("get cfn stackset operations", "accountId", accountConfig.Id, "region", region)
+
+	cfn := cloudformation.New(newSession(accountConfig, region))
+
+	stackSets, err := listMatchedStackSets(cfn, accountConfig.Filters)
+	if err != nil {
+		return append(views, &CfnStackSetOperationsView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Error:       err,
+		})
+	}
+
+	for _, stackSet := range stackSets {
+		stackSetName := *stackSet.StackSetName
+		operations, err := c.listOperations(cfn, stackSetName)
+		views = append(views, &CfnStackSetOperationsView{
+			AccountId:    accountConfig.Id,
+			AccountName:  accountConfig.Name,
+			Region:       region,
+			StackSetName: stackSetName,
+			Operations:   operations,
+			Error:        err,
+		})
+	}
+
+	return views
+}
+
+func (c *StackSetOperationsCmd) listOperations(cfn cloudformationiface.CloudFormationAPI, stackSetName string) ([]CfnStackSetOperation, error) {
+	var operations []CfnStackSetOperation
+	input := &cloudformation.ListStackSetOperationsInput{
+		StackSetName: aws.String(stackSetName),
+	}
+	for {
+		listOperationsOutput, err := cfn.ListStackSetOperations(input)
+		if err != nil {
+			return operations, err
+		}
+		for _, summary := range listOperationsOutput.Summaries {
+			if summary.CreationTimestamp != nil && summary.CreationTimestamp.Before(c.sinceTime) {
+				continue
+			}
+			operationFailed := isFailedStackSetOperationStatus(aws.StringValue(summary.Status))
+
+			results, err := c.listOperationResults(cfn, stackSetName, *summary.OperationId)
+			if err != nil {
+				return operations, err
+			}
+			if c.failuresOnly && !operationFailed && len(results) == 0 {
+				continue
+			}
+
+			duration := ""
+			if summary.CreationTimestamp != nil && summary.EndTimestamp != nil {
+				duration = summary.EndTimestamp.Sub(*summary.CreationTimestamp).String()
+			}
+			operations = append(operations, CfnStackSetOperation{
+				OperationId:  *summary.OperationId,
+				Action:       aws.StringValue(summary.Action),
+				Status:       aws.StringValue(summary.Status),
+				StatusReason: aws.StringValue(summary.StatusReason),
+				CreationTime: formatTime(summary.CreationTimestamp),
+				EndTime:      formatTime(summary.EndTimestamp),
+				Duration:     duration,
+				Results:      results,
+			})
+		}
+		if listOperationsOutput.NextToken == nil {
+			break
+		}
+		input.NextToken = listOperationsOutput.NextToken
+	}
+	return operations, nil
+}
+
+// listOperationResults returns per stack instance results of the operation.
+// if failuresOnly is set, only failed results are returned.
+func (c *StackSetOperationsCmd) listOperationResults(cfn cloudformationiface.CloudFormationAPI, stackSetName, operationId string) ([]CfnStackSetOperationResult, error) {
+	var results []CfnStackSetOperationResult
+	input := &cloudformation.ListStackSetOperationResultsInput{
+		StackSetName: aws.String(stackSetName),
+		OperationId:  aws.String(operationId),
+	}
+	for {
+		listResultsOutput, err := cfn.ListStackSetOperationResults(input)
+		if err != nil {
+			return results, err
+		}
+		for _, summary := range listResultsOutput.Summaries {
+			status := aws.StringValue(summary.Status)
+			if c.failuresOnly && status != cloudformation.StackSetOperationResultStatusFailed {
+				continue
+			}
+			results = append(results, CfnStackSetOperationResult{
+				Account:      aws.StringValue(summary.Account),
+				Region:       aws.StringValue(summary.Region),
+				Status:       status,
+				StatusReason: aws.StringValue(summary.StatusReason),
+			})
+		}
+		if listResultsOutput.NextToken == nil {
+			break
+		}
+		input.NextToken = listResultsOutput.NextToken
+	}
+	return results, nil
+}
+
+func isFailedStackSetOperationStatus(status string) bool {
+	return status == cloudformation.StackSetOperationStatusFailed || status == cloudformation.StackSetOperationStatusStopped
+}
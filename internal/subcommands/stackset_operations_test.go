@@ -0,0 +1,87 @@
+package subcommands
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gookit/config/v2"
+	cfnConfig "github.com/horietakehiro/cfn-global-views/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackSetOperations_valid(t *testing.T) {
+	defer config.ClearAll()
+
+	configPath := "../../config/test_config.yaml"
+
+	c, err := cfnConfig.GetConfig(configPath)
+	assert.Nil(t, err)
+
+	cmd := StackSetOperationsCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.NotEqual(t, 0, len(views))
+	for _, v := range views {
+		assert.Nil(t, v.Error)
+		if v.StackSetName != "" {
+			assert.Contains(t, v.StackSetName, "StackSet")
+		}
+	}
+}
+
+func TestStackSetOperations_invalid(t *testing.T) {
+	c := getInvalidConfig(t, "")
+
+	cmd := StackSetOperationsCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 1, len(views))
+	assert.NotNil(t, views[0].Error, views[0])
+
+}
+
+func TestStackSetOperations_csv_views(t *testing.T) {
+	cmd := StackSetOperationsCmd{}
+
+	views := []*CfnStackSetOperationsView{
+		{
+			AccountId:    "123456789012",
+			StackSetName: "no-operations",
+		},
+		{
+			AccountId:    "123456789012",
+			StackSetName: "failed",
+			Operations: []CfnStackSetOperation{
+				{
+					OperationId: "op-1",
+					Status:      "FAILED",
+					Results: []CfnStackSetOperationResult{
+						{Account: "111111111111", Region: "ap-northeast-1", Status: "FAILED", StatusReason: "reason"},
+						{Account: "222222222222", Region: "ap-northeast-1", Status: "FAILED", StatusReason: "reason"},
+					},
+				},
+				{
+					OperationId: "op-2",
+					Status:      "SUCCEEDED",
+				},
+			},
+			Error: fmt.Errorf("error"),
+		},
+	}
+
+	csvViews := cmd.toCsvViews(views)
+	assert.Equal(t, 4, len(csvViews))
+	assert.Equal(t, "", csvViews[0].OperationId)
+	assert.Equal(t, "op-1", csvViews[1].OperationId)
+	assert.Equal(t, "111111111111", csvViews[1].ResultAccount)
+	assert.Equal(t, "222222222222", csvViews[2].ResultAccount)
+	assert.Equal(t, "op-2", csvViews[3].OperationId)
+	assert.Equal(t, "", csvViews[3].ResultAccount)
+	assert.Equal(t, "error", csvViews[3].Error)
+}
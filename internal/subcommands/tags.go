@@ -0,0 +1,188 @@
+package subcommands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/google/subcommands"
+	"golang.org/x/exp/slog"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+type CfnTagsView struct {
+	AccountId   string
+	AccountName string
+	Region      string
+	StackName   string
+	Tags        []CfnTag
+	Error       error
+}
+
+type CfnTagsCsvView struct {
+	AccountId   string
+	AccountName string
+	Region      string
+	StackName   string
+	TagKey      string
+	TagValue    string
+	Error       string
+}
+
+type CfnTagsMatrixCsvView struct {
+	AccountId   string
+	AccountName string
+	Region      string
+	StackName   string
+	Error       string
+}
+
+type TagsCmd struct {
+	subcommands.Command
+	configFilePath string
+	outFilePath    string
+	format         string
+	verbose        bool
+	matrix         bool
+	logger         *slog.Logger
+	config         *config.CfnGlobalViewsConfig
+	collected      func(views []*CfnTagsView)
+}
+
+func (*TagsCmd) Name() string {
+	return "tags"
+}
+func (*TagsCmd) Synopsis() string {
+	return "list cfn stack tags"
+}
+func (*TagsCmd) Usage() string {
+	return "tags -c path/to/config.yaml [-matrix]"
+}
+func (c *TagsCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
+	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
+	f.StringVar(&c.format, "f", "csv", formatUsage("csv"))
+	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+	f.BoolVar(&c.matrix, "matrix", false, "if set, output csv and excel as a matrix of stacks x tag keys instead of a row per stack and tag")
+}
+
+func (c *TagsCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return subcommands.ExitFailure
+	}
+
+	return exitStatus(c.logger, writeGlobalViews(c.format, c.outFilePath, &c.collected, c.GetGlobalViews, c.Report))
+}
+
+func (c *TagsCmd) toTable(views []*CfnTagsView) *table {
+	if c.matrix {
+		return c.toMatrixTable(views)
+	}
+
+	csvViews := []CfnTagsCsvView{}
+	for _, view := range views {
+		if len(view.Tags) == 0 {
+			csvViews = append(csvViews, CfnTagsCsvView{
+				AccountId:   view.AccountId,
+				AccountName: view.AccountName,
+				Region:      view.Region,
+				StackName:   view.StackName,
+				Error:       errorString(view.Error),
+			})
+		}
+		for _, tag := range view.Tags {
+			csvViews = append(csvViews, CfnTagsCsvView{
+				AccountId:   view.AccountId,
+				AccountName: view.AccountName,
+				Region:      view.Region,
+				StackName:   view.StackName,
+				TagKey:      tag.Key,
+				TagValue:    tag.Value,
+				Error:       errorString(view.Error),
+			})
+		}
+	}
+	return newTable(csvViews)
+}
+
+// toMatrixTable returns a table with a row per stack and a column per tag key found in any stack.
+func (c *TagsCmd) toMatrixTable(views []*CfnTagsView) *table {
+	csvViews := []CfnTagsMatrixCsvView{}
+	rowTags := [][]CfnTag{}
+	tagKeys := []string{}
+	found := map[string]bool{}
+
+	for _, view := range views {
+		csvViews = append(csvViews, CfnTagsMatrixCsvView{
+			AccountId:   view.AccountId,
+			AccountName: view.AccountName,
+			Region:      view.Region,
+			StackName:   view.StackName,
+			Error:       errorString(view.Error),
+		})
+		rowTags = append(rowTags, view.Tags)
+		for _, tag := range view.Tags {
+			if !found[tag.Key] {
+				found[tag.Key] = true
+				tagKeys = append(tagKeys, tag.Key)
+			}
+		}
+	}
+	sort.Strings(tagKeys)
+
+	t := newTable(csvViews)
+	t.insertTagColumns(tagKeys, rowTags)
+	return t
+}
+
+func (c *TagsCmd) Report(views []*CfnTagsView) *output.Report {
+	return &output.Report{Tables: []*output.Table{c.toTable(views).toOutput(c.Name(), views)}}
+}
+
+func (c *TagsCmd) GetGlobalViews() []*CfnTagsView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, c.collected)
+
+	sortViews(globalViews, func(view *CfnTagsView) []string {
+		return []string{view.AccountId, view.Region, view.StackName}
+	})
+
+	return globalViews
+}
+
+func (c *TagsCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnTagsView {
+	views := []*CfnTagsView{}
+	c.logger.Info("get cfn views", "accountId", accountConfig.Id, "region", region)
+
+	cfn := cloudformation.New(newSession(accountConfig, region))
+
+	matchedStacks, err := describeMatchedStacks(cfn, accountConfig.Filters)
+	if err != nil {
+		return append(views, &CfnTagsView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Error:       err,
+		})
+	}
+
+	for _, matchedStack := range matchedStacks {
+		views = append(views, &CfnTagsView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			StackName:   *matchedStack.StackName,
+			Tags:        newCfnTags(matchedStack.Tags),
+			Error:       nil,
+		})
+	}
+
+	return views
+}
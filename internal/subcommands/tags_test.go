@@ -0,0 +1,63 @@
+package subcommands
+
+import (
+	"testing"
+
+	"github.com/gookit/config/v2"
+	cfnConfig "github.com/horietakehiro/cfn-global-views/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTags_valid(t *testing.T) {
+	defer config.ClearAll()
+
+	configPath := "../../config/test_config.yaml"
+
+	c, err := cfnConfig.GetConfig(configPath)
+	assert.Nil(t, err)
+
+	cmd := TagsCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 4, len(views))
+	for _, v := range views {
+		assert.Equal(t, "cfn-global-views", tagValues(v.Tags, []string{"APP"})[0])
+		assert.Nil(t, v.Error)
+	}
+}
+
+func TestTags_invalid(t *testing.T) {
+	c := getInvalidConfig(t, "")
+
+	cmd := TagsCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 1, len(views))
+	assert.NotNil(t, views[0].Error, views[0])
+
+}
+
+func TestTags_matrix(t *testing.T) {
+	views := []*CfnTagsView{
+		{StackName: "stack-1", Tags: []CfnTag{{Key: "ENV", Value: "test"}, {Key: "APP", Value: "app-1"}}},
+		{StackName: "stack-2", Tags: []CfnTag{{Key: "ENV", Value: "prod"}}},
+	}
+
+	cmd := TagsCmd{}
+	table := cmd.toTable(views)
+	assert.Equal(t, []string{"AccountId", "AccountName", "Region", "StackName", "TagKey", "TagValue", "Error"}, table.header)
+	assert.Equal(t, 3, len(table.records))
+
+	cmd = TagsCmd{matrix: true}
+	table = cmd.toTable(views)
+	assert.Equal(t, []string{"AccountId", "AccountName", "Region", "StackName", "Tag:APP", "Tag:ENV", "Error"}, table.header)
+	assert.Equal(t, 2, len(table.records))
+	assert.Equal(t, []interface{}{"", "", "", "stack-1", "app-1", "test", ""}, table.records[0])
+	assert.Equal(t, []interface{}{"", "", "", "stack-2", "", "prod", ""}, table.records[1])
+}
@@ -0,0 +1,439 @@
+package subcommands
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/google/subcommands"
+	"golang.org/x/exp/slog"
+	"gopkg.in/yaml.v3"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+const (
+	TEMPLATE_DIFFERENCE_ADDED    = "ADDED"
+	TEMPLATE_DIFFERENCE_REMOVED  = "REMOVED"
+	TEMPLATE_DIFFERENCE_MODIFIED = "MODIFIED"
+)
+
+var (
+	// sections diffed per logical id. other sections are diffed as a whole
+	TEMPLATE_DIFF_SECTIONS = []string{"Parameters", "Resources", "Outputs"}
+)
+
+// CfnTemplateDifference is a difference of the local template relative to the deployed template,
+// e.g. ADDED means the local template has the entry but the deployed one doesn't.
+type CfnTemplateDifference struct {
+	Section        string
+	LogicalId      string
+	DifferenceType string
+	DeployedValue  string
+	LocalValue     string
+}
+
+type CfnTemplateDiffView struct {
+	AccountId    string
+	AccountName  string
+	Region       string
+	StackName    string
+	TemplatePath string
+	Matches      bool
+	Differences  []CfnTemplateDifference
+	Error        error
+}
+
+type CfnTemplateDiffCsvView struct {
+	AccountId               string
+	AccountName             string
+	Region                  string
+	StackName               string
+	TemplatePath            string
+	TemplateMatches         string
+	DifferenceSection       string
+	DifferenceLogicalId     string
+	DifferenceType          string
+	DifferenceDeployedValue string
+	DifferenceLocalValue    string
+	Error                   string
+}
+
+type TemplateDiffCmd struct {
+	subcommands.Command
+	configFilePath string
+	outFilePath    string
+	format         string
+	verbose        bool
+	logger         *slog.Logger
+	config         *config.CfnGlobalViewsConfig
+	collected      func(views []*CfnTemplateDiffView)
+
+	localTemplatesLock sync.Mutex
+	localTemplates     map[string]map[string]interface{}
+}
+
+func (*TemplateDiffCmd) Name() string {
+	return "template-diff"
+}
+func (*TemplateDiffCmd) Synopsis() string {
+	return "compare deployed cfn templates against local template files mapped in the config"
+}
+func (*TemplateDiffCmd) Usage() string {
+	return "template-diff -c path/to/config.yaml"
+}
+func (c *TemplateDiffCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file. stacks are mapped to local templates by TemplateMappings")
+	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
+	f.StringVar(&c.format, "f", "csv", formatUsage("csv"))
+	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+}
+
+func (c *TemplateDiffCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return subcommands.ExitFailure
+	}
+	if len(c.config.TemplateMappings) == 0 {
+		fmt.Println("at least 1 TemplateMappings is required in the config")
+		return subcommands.ExitFailure
+	}
+
+	return exitStatus(c.logger, writeGlobalViews(c.format, c.outFilePath, &c.collected, c.GetGlobalViews, c.Report))
+}
+
+func (c *TemplateDiffCmd) toCsvViews(views []*CfnTemplateDiffView) []CfnTemplateDiffCsvView {
+	csvViews := []CfnTemplateDiffCsvView{}
+
+	for _, view := range views {
+		base := CfnTemplateDiffCsvView{
+			AccountId:    view.AccountId,
+			AccountName:  view.AccountName,
+			Region:       view.Region,
+			StackName:    view.StackName,
+			TemplatePath: view.TemplatePath,
+			Error:        errorString(view.Error),
+		}
+		if view.StackName != "" && view.Error == nil {
+			base.TemplateMatches = strconv.FormatBool(view.Matches)
+		}
+		if len(view.Differences) == 0 {
+			csvViews = append(csvViews, base)
+		}
+		for _, difference := range view.Differences {
+			row := base
+			row.DifferenceSection = difference.Section
+			row.DifferenceLogicalId = difference.LogicalId
+			row.DifferenceType = difference.DifferenceType
+			row.DifferenceDeployedValue = difference.DeployedValue
+			row.DifferenceLocalValue = difference.LocalValue
+			csvViews = append(csvViews, row)
+		}
+	}
+
+	return csvViews
+}
+
+func (c *TemplateDiffCmd) Report(views []*CfnTemplateDiffView) *output.Report {
+	return &output.Report{Tables: []*output.Table{newTable(c.toCsvViews(views)).toOutput(c.Name(), views)}}
+}
+
+func (c *TemplateDiffCmd) GetGlobalViews() []*CfnTemplateDiffView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, c.collected)
+
+	sortViews(globalViews, func(view *CfnTemplateDiffView) []string {
+		return []string{view.AccountId, view.Region, view.StackName}
+	})
+
+	return globalViews
+}
+
+// getViews compares matched stacks mapped to local templates. stacks without mappings are skipped.
+func (c *TemplateDiffCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnTemplateDiffView {
+	views := []*CfnTemplateDiffView{}
+	c.logger.Info("get cfn template diffs", "accountId", accountConfig.Id, "region", region)
+
+	cfn := cloudformation.New(newSession(accountConfig, region))
+
+	matchedStacks, err := describeMatchedStacks(cfn, accountConfig.Filters)
+	if err != nil {
+		return append(views, &CfnTemplateDiffView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Error:       err,
+		})
+	}
+
+	for _, matchedStack := range matchedStacks {
+		templatePath := findTemplateMapping(c.config.TemplateMappings, matchedStack)
+		if templatePath == "" {
+			continue
+		}
+		view := &CfnTemplateDiffView{
+			AccountId:    accountConfig.Id,
+			AccountName:  accountConfig.Name,
+			Region:       region,
+			StackName:    *matchedStack.StackName,
+			TemplatePath: templatePath,
+		}
+		views = append(views, view)
+
+		local, err := c.getLocalTemplate(templatePath)
+		if err != nil {
+			view.Error = err
+			continue
+		}
+		body, err := getTemplateBody(cfn, *matchedStack.StackId, cloudformation.TemplateStageOriginal)
+		if err != nil {
+			view.Error = err
+			continue
+		}
+		deployed, err := parseTemplate(body)
+		if err != nil {
+			view.Error = err
+			continue
+		}
+		view.Differences = diffTemplates(deployed, local)
+		view.Matches = len(view.Differences) == 0
+	}
+
+	return views
+}
+
+// getLocalTemplate parses the local template file once and caches it for other stacks.
+func (c *TemplateDiffCmd) getLocalTemplate(templatePath string) (map[string]interface{}, error) {
+	c.localTemplatesLock.Lock()
+	defer c.localTemplatesLock.Unlock()
+
+	if template, ok := c.localTemplates[templatePath]; ok {
+		return template, nil
+	}
+	body, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	template, err := parseTemplate(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", templatePath, err.Error())
+	}
+	if c.localTemplates == nil {
+		c.localTemplates = map[string]map[string]interface{}{}
+	}
+	c.localTemplates[templatePath] = template
+	return template, nil
+}
+
+// findTemplateMapping returns the template path of the first mapping matching the stack,
+// or an empty string if no mappings match.
+func findTemplateMapping(templateMappings []config.TemplateMapping, stack *cloudformation.Stack) string {
+	for _, templateMapping := range templateMappings {
+		matched, _ := regexp.MatchString(templateMapping.StackNameRegex, *stack.StackName)
+		if matched && hasAllTags(stack.Tags, templateMapping.StackTags) {
+			return templateMapping.TemplatePath
+		}
+	}
+	return ""
+}
+
+// diffTemplates returns differences of the local template relative to the deployed template.
+func diffTemplates(deployed, local map[string]interface{}) []CfnTemplateDifference {
+	differences := []CfnTemplateDifference{}
+
+	for _, section := range sortedUnionKeys(deployed, local) {
+		deployedSection, deployedIsMap := deployed[section].(map[string]interface{})
+		localSection, localIsMap := local[section].(map[string]interface{})
+		isDiffSection := false
+		for _, s := range TEMPLATE_DIFF_SECTIONS {
+			isDiffSection = isDiffSection || s == section
+		}
+		if !isDiffSection || !deployedIsMap || !localIsMap {
+			if difference, ok := diffTemplateValues(section, "", deployed, local, section); ok {
+				differences = append(differences, difference)
+			}
+			continue
+		}
+		for _, logicalId := range sortedUnionKeys(deployedSection, localSection) {
+			if difference, ok := diffTemplateValues(section, logicalId, deployedSection, localSection, logicalId); ok {
+				differences = append(differences, difference)
+			}
+		}
+	}
+
+	return differences
+}
+
+func diffTemplateValues(section, logicalId string, deployed, local map[string]interface{}, key string) (CfnTemplateDifference, bool) {
+	deployedValue, inDeployed := deployed[key]
+	localValue, inLocal := local[key]
+	difference := CfnTemplateDifference{
+		Section:       section,
+		LogicalId:     logicalId,
+		DeployedValue: compactJson(deployedValue),
+		LocalValue:    compactJson(localValue),
+	}
+	switch {
+	case !inDeployed:
+		difference.DifferenceType = TEMPLATE_DIFFERENCE_ADDED
+		difference.DeployedValue = ""
+	case !inLocal:
+		difference.DifferenceType = TEMPLATE_DIFFERENCE_REMOVED
+		difference.LocalValue = ""
+	case !reflect.DeepEqual(deployedValue, localValue):
+		difference.DifferenceType = TEMPLATE_DIFFERENCE_MODIFIED
+	default:
+		return difference, false
+	}
+	return difference, true
+}
+
+func sortedUnionKeys(a, b map[string]interface{}) []string {
+	keys := []string{}
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func compactJson(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// parseTemplate parses a json or yaml template into a normalized tree, so that templates differing only
+// in formats, key orders, short form intrinsic functions or scalar types are deeply equal.
+func parseTemplate(body string) (map[string]interface{}, error) {
+	var tree interface{}
+	if templateFormat(body) == TEMPLATE_FORMAT_JSON {
+		if err := json.Unmarshal([]byte(body), &tree); err != nil {
+			return nil, err
+		}
+	} else {
+		var node yaml.Node
+		if err := yaml.Unmarshal([]byte(body), &node); err != nil {
+			return nil, err
+		}
+		var err error
+		tree, err = yamlNodeToValue(&node)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	template, ok := normalizeTemplateValue(tree).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("template must be a mapping")
+	}
+	return template, nil
+}
+
+// yamlNodeToValue converts a yaml node into a json compatible value,
+// expanding short form intrinsic functions such as !Ref and !GetAtt into their full forms.
+func yamlNodeToValue(node *yaml.Node) (interface{}, error) {
+	var value interface{}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlNodeToValue(node.Content[0])
+	case yaml.AliasNode:
+		return yamlNodeToValue(node.Alias)
+	case yaml.MappingNode:
+		m := map[string]interface{}{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			v, err := yamlNodeToValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[node.Content[i].Value] = v
+		}
+		value = m
+	case yaml.SequenceNode:
+		l := []interface{}{}
+		for _, child := range node.Content {
+			v, err := yamlNodeToValue(child)
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, v)
+		}
+		value = l
+	case yaml.ScalarNode:
+		// scalars are kept as written, e.g. dates such as 2010-09-09 are not decoded as timestamps
+		if node.ShortTag() != "!!null" {
+			value = node.Value
+		}
+	}
+
+	if !isShortFormTag(node.Tag) {
+		return value, nil
+	}
+	name := strings.TrimPrefix(node.Tag, "!")
+	switch name {
+	case "Ref", "Condition":
+		return map[string]interface{}{name: value}, nil
+	case "GetAtt":
+		if s, ok := value.(string); ok {
+			parts := strings.SplitN(s, ".", 2)
+			l := []interface{}{}
+			for _, part := range parts {
+				l = append(l, part)
+			}
+			value = l
+		}
+	}
+	return map[string]interface{}{"Fn::" + name: value}, nil
+}
+
+func isShortFormTag(tag string) bool {
+	return strings.HasPrefix(tag, "!") && !strings.HasPrefix(tag, "!!")
+}
+
+// normalizeTemplateValue converts scalars into strings, as cfn does for most property values.
+func normalizeTemplateValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for key, value := range v {
+			m[key] = normalizeTemplateValue(value)
+		}
+		return m
+	case []interface{}:
+		l := []interface{}{}
+		for _, value := range v {
+			l = append(l, normalizeTemplateValue(value))
+		}
+		return l
+	case nil:
+		return nil
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
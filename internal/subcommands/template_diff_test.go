@@ -0,0 +1,123 @@
+package subcommands
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	cfnConfig "github.com/horietakehiro/cfn-global-views/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	TEST_YAML_TEMPLATE = `
+AWSTemplateFormatVersion: 2010-09-09
+Parameters:
+  Env:
+    Type: String
+    Default: dev
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: !Sub "${Env}-bucket"
+      VersioningConfiguration:
+        Status: !If [IsProd, Enabled, !Ref AWS::NoValue]
+Outputs:
+  BucketArn:
+    Value: !GetAtt Bucket.Arn
+`
+	TEST_JSON_TEMPLATE = `{
+  "Outputs": {"BucketArn": {"Value": {"Fn::GetAtt": ["Bucket", "Arn"]}}},
+  "Resources": {
+    "Bucket": {
+      "Type": "AWS::S3::Bucket",
+      "Properties": {
+        "VersioningConfiguration": {"Status": {"Fn::If": ["IsProd", "Enabled", {"Ref": "AWS::NoValue"}]}},
+        "BucketName": {"Fn::Sub": "${Env}-bucket"}
+      }
+    }
+  },
+  "Parameters": {"Env": {"Type": "String", "Default": "dev"}},
+  "AWSTemplateFormatVersion": "2010-09-09"
+}`
+)
+
+func TestTemplateDiff_invalid(t *testing.T) {
+	c := getInvalidConfig(t, `
+TemplateMappings:
+  - TemplatePath: template.yaml
+`)
+
+	cmd := TemplateDiffCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 1, len(views))
+	assert.NotNil(t, views[0].Error, views[0])
+
+}
+
+func TestTemplateDiff_parseTemplate(t *testing.T) {
+	yamlTemplate, err := parseTemplate(TEST_YAML_TEMPLATE)
+	assert.Nil(t, err)
+	jsonTemplate, err := parseTemplate(TEST_JSON_TEMPLATE)
+	assert.Nil(t, err)
+
+	assert.Equal(t, jsonTemplate, yamlTemplate)
+	assert.Equal(t, 0, len(diffTemplates(jsonTemplate, yamlTemplate)))
+}
+
+func TestTemplateDiff_diffTemplates(t *testing.T) {
+	deployed, err := parseTemplate(TEST_JSON_TEMPLATE)
+	assert.Nil(t, err)
+	local, err := parseTemplate(`
+AWSTemplateFormatVersion: "2010-09-09"
+Description: added description
+Parameters:
+  Env:
+    Type: String
+    Default: prod
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+  Queue:
+    Type: AWS::SQS::Queue
+`)
+	assert.Nil(t, err)
+
+	differences := diffTemplates(deployed, local)
+	assert.Equal(t, []string{"Description", "Outputs", "Parameters", "Resources", "Resources"}, func() []string {
+		sections := []string{}
+		for _, d := range differences {
+			sections = append(sections, d.Section)
+		}
+		return sections
+	}())
+	assert.Equal(t, TEMPLATE_DIFFERENCE_ADDED, differences[0].DifferenceType)
+	assert.Equal(t, "", differences[0].LogicalId)
+	assert.Equal(t, TEMPLATE_DIFFERENCE_REMOVED, differences[1].DifferenceType)
+	assert.Equal(t, "Env", differences[2].LogicalId)
+	assert.Equal(t, TEMPLATE_DIFFERENCE_MODIFIED, differences[2].DifferenceType)
+	assert.Equal(t, "Bucket", differences[3].LogicalId)
+	assert.Equal(t, TEMPLATE_DIFFERENCE_MODIFIED, differences[3].DifferenceType)
+	assert.Equal(t, "Queue", differences[4].LogicalId)
+	assert.Equal(t, TEMPLATE_DIFFERENCE_ADDED, differences[4].DifferenceType)
+	assert.Equal(t, `{"Type":"AWS::SQS::Queue"}`, differences[4].LocalValue)
+}
+
+func TestTemplateDiff_findTemplateMapping(t *testing.T) {
+	templateMappings := []cfnConfig.TemplateMapping{
+		{StackNameRegex: "^network", TemplatePath: "network.yaml"},
+		{StackTags: []cfnConfig.Tag{{Key: "APP", Value: "web"}}, TemplatePath: "web.yaml"},
+	}
+	stack := func(name string, tags ...*cloudformation.Tag) *cloudformation.Stack {
+		return &cloudformation.Stack{StackName: aws.String(name), Tags: tags}
+	}
+
+	assert.Equal(t, "network.yaml", findTemplateMapping(templateMappings, stack("network-dev")))
+	assert.Equal(t, "web.yaml", findTemplateMapping(templateMappings, stack("web-dev", &cloudformation.Tag{Key: aws.String("APP"), Value: aws.String("web")})))
+	assert.Equal(t, "", findTemplateMapping(templateMappings, stack("web-dev")))
+}
@@ -0,0 +1,440 @@
+package subcommands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/google/subcommands"
+	"golang.org/x/exp/slog"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+var (
+	// stack set instances are deployed as stacks named StackSet-<stack set name>-<uuid>
+	stackSetInstanceStackNameRegex = regexp.MustCompile(`^StackSet-(.+)-[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+)
+
+type CfnTemplateVersionsView struct {
+	AccountId       string
+	AccountName     string
+	Region          string
+	StackName       string
+	StackSetName    string
+	TemplateHash    string
+	LastUpdatedTime string
+	Error           error
+}
+
+type CfnTemplateVersion struct {
+	TemplateHash          string
+	StackCount            int
+	Stacks                []string
+	NewestLastUpdatedTime string
+	// Error is set for a stack whose template failed to be fetched, or an account and region failing to describe stacks
+	Error string `json:",omitempty"`
+}
+
+type CfnTemplateVersionsCsvView struct {
+	TemplateHash          string
+	StackCount            string
+	Stacks                string
+	NewestLastUpdatedTime string
+	Error                 string
+}
+
+// CfnTemplateSkew is a stack in a group of stacks which are expected to have the same template,
+// i.e. instances of the same stack set or stacks matching the same name pattern.
+type CfnTemplateSkew struct {
+	Group           string
+	AccountId       string
+	AccountName     string
+	Region          string
+	StackName       string
+	TemplateHash    string
+	MajorityHash    string
+	LastUpdatedTime string
+	Outdated        bool
+	Error           string `json:",omitempty"`
+}
+
+type CfnTemplateSkewsCsvView struct {
+	Group           string
+	AccountId       string
+	AccountName     string
+	Region          string
+	StackName       string
+	TemplateHash    string
+	MajorityHash    string
+	LastUpdatedTime string
+	Outdated        string
+	Error           string
+}
+
+type TemplateVersionsCmd struct {
+	subcommands.Command
+	configFilePath string
+	outFilePath    string
+	format         string
+	verbose        bool
+	skew           bool
+	namePatterns   []string
+	logger         *slog.Logger
+	config         *config.CfnGlobalViewsConfig
+	collected      func(views []*CfnTemplateVersionsView)
+}
+
+func (*TemplateVersionsCmd) Name() string {
+	return "template-versions"
+}
+func (*TemplateVersionsCmd) Synopsis() string {
+	return "group cfn stacks by template hashes to find version skews"
+}
+func (*TemplateVersionsCmd) Usage() string {
+	return "template-versions -c path/to/config.yaml [-skew] [-name-patterns ^app-.*$,^network-.*$]"
+}
+func (c *TemplateVersionsCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
+	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
+	f.StringVar(&c.format, "f", "csv", formatUsage("csv"))
+	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+	f.BoolVar(&c.skew, "skew", false, "if set, output stacks grouped by stack sets or name patterns with their majority hashes instead of template versions. excel output always has both sheets")
+	f.Var((*listFlag)(&c.namePatterns), "name-patterns", "comma separated regex patterns of stack names. stacks matching the same pattern are expected to have the same template")
+}
+
+func (c *TemplateVersionsCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, c.validate)
+	if err != nil {
+		fmt.Println(err.Error())
+		return subcommands.ExitFailure
+	}
+
+	return exitStatus(c.logger, writeGlobalViews(c.format, c.outFilePath, &c.collected, c.GetGlobalViews, c.Report))
+}
+
+// validate checks '-name-patterns' arg.
+func (c *TemplateVersionsCmd) validate() error {
+	for _, pattern := range c.namePatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regex pattern for arg '-name-patterns': %s", pattern)
+		}
+	}
+	return nil
+}
+
+func (c *TemplateVersionsCmd) toCsvViews(versions []CfnTemplateVersion) []CfnTemplateVersionsCsvView {
+	csvViews := []CfnTemplateVersionsCsvView{}
+	for _, version := range versions {
+		csvViews = append(csvViews, CfnTemplateVersionsCsvView{
+			TemplateHash:          version.TemplateHash,
+			StackCount:            strconv.Itoa(version.StackCount),
+			Stacks:                strings.Join(version.Stacks, ","),
+			NewestLastUpdatedTime: version.NewestLastUpdatedTime,
+			Error:                 version.Error,
+		})
+	}
+	return csvViews
+}
+
+func (c *TemplateVersionsCmd) toSkewCsvViews(skews []CfnTemplateSkew) []CfnTemplateSkewsCsvView {
+	csvViews := []CfnTemplateSkewsCsvView{}
+	for _, skew := range skews {
+		csvViews = append(csvViews, CfnTemplateSkewsCsvView{
+			Group:           skew.Group,
+			AccountId:       skew.AccountId,
+			AccountName:     skew.AccountName,
+			Region:          skew.Region,
+			StackName:       skew.StackName,
+			TemplateHash:    skew.TemplateHash,
+			MajorityHash:    skew.MajorityHash,
+			LastUpdatedTime: skew.LastUpdatedTime,
+			Outdated:        strconv.FormatBool(skew.Outdated),
+			Error:           skew.Error,
+		})
+	}
+	return csvViews
+}
+
+// Report has template versions and skews, and the skews are the main table if requested.
+// both tables aggregate views of all accounts and regions.
+func (c *TemplateVersionsCmd) Report(views []*CfnTemplateVersionsView) *output.Report {
+	versions := c.GetVersions(views)
+	skews := c.GetSkews(views)
+	report := &output.Report{Tables: []*output.Table{
+		newTable(c.toCsvViews(versions)).toOutput(c.Name(), versions),
+		newTable(c.toSkewCsvViews(skews)).toOutput("template-skews", skews),
+	}, Aggregated: true}
+	if c.skew {
+		report.Main = 1
+	}
+	return report
+}
+
+// GetVersions groups stacks by template hashes, in descending order of stack counts.
+// stacks whose templates failed to be fetched follow as error rows.
+func (c *TemplateVersionsCmd) GetVersions(views []*CfnTemplateVersionsView) []CfnTemplateVersion {
+	versions := []CfnTemplateVersion{}
+	errorVersions := []CfnTemplateVersion{}
+	indices := map[string]int{}
+	for _, view := range views {
+		if view.Error != nil {
+			// an account and region failing to describe stacks has no stack to count
+			errorVersion := CfnTemplateVersion{
+				Stacks: []string{fmt.Sprintf("%s/%s", view.AccountId, view.Region)},
+				Error:  view.Error.Error(),
+			}
+			if view.StackName != "" {
+				errorVersion.StackCount = 1
+				errorVersion.Stacks = []string{fmt.Sprintf("%s/%s/%s", view.AccountId, view.Region, view.StackName)}
+			}
+			errorVersions = append(errorVersions, errorVersion)
+			continue
+		}
+		i, ok := indices[view.TemplateHash]
+		if !ok {
+			i = len(versions)
+			indices[view.TemplateHash] = i
+			versions = append(versions, CfnTemplateVersion{TemplateHash: view.TemplateHash})
+		}
+		versions[i].StackCount++
+		versions[i].Stacks = append(versions[i].Stacks, fmt.Sprintf("%s/%s/%s", view.AccountId, view.Region, view.StackName))
+		// timestamps are RFC3339 in UTC, so they are compared as strings
+		if view.LastUpdatedTime > versions[i].NewestLastUpdatedTime {
+			versions[i].NewestLastUpdatedTime = view.LastUpdatedTime
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].StackCount > versions[j].StackCount })
+	return append(versions, errorVersions...)
+}
+
+// GetSkews lists stacks in groups of stack sets or name patterns with the majority hash of each group.
+// if hashes are tied for the majority, the most recently updated one is chosen.
+// stacks whose templates failed to be fetched follow as error rows, which don't count for majorities.
+func (c *TemplateVersionsCmd) GetSkews(views []*CfnTemplateVersionsView) []CfnTemplateSkew {
+	groups := []string{}
+	groupViews := map[string][]*CfnTemplateVersionsView{}
+	errorSkews := []CfnTemplateSkew{}
+	for _, view := range views {
+		if view.Error != nil {
+			errorSkews = append(errorSkews, CfnTemplateSkew{
+				Group:       c.groupOf(view),
+				AccountId:   view.AccountId,
+				AccountName: view.AccountName,
+				Region:      view.Region,
+				StackName:   view.StackName,
+				Error:       view.Error.Error(),
+			})
+			continue
+		}
+		group := c.groupOf(view)
+		if group == "" {
+			continue
+		}
+		if _, ok := groupViews[group]; !ok {
+			groups = append(groups, group)
+		}
+		groupViews[group] = append(groupViews[group], view)
+	}
+	sort.Strings(groups)
+
+	skews := []CfnTemplateSkew{}
+	for _, group := range groups {
+		majorityHash := c.majorityHash(groupViews[group])
+		for _, view := range groupViews[group] {
+			skews = append(skews, CfnTemplateSkew{
+				Group:           group,
+				AccountId:       view.AccountId,
+				AccountName:     view.AccountName,
+				Region:          view.Region,
+				StackName:       view.StackName,
+				TemplateHash:    view.TemplateHash,
+				MajorityHash:    majorityHash,
+				LastUpdatedTime: view.LastUpdatedTime,
+				Outdated:        view.TemplateHash != majorityHash,
+			})
+		}
+	}
+	return append(skews, errorSkews...)
+}
+
+// groupOf returns StackSet:<stack set name> for stack set instances, Pattern:<pattern> for the first name pattern
+// matching the stack, or an empty string if the stack doesn't belong to any groups.
+func (c *TemplateVersionsCmd) groupOf(view *CfnTemplateVersionsView) string {
+	if view.StackSetName != "" {
+		return "StackSet:" + view.StackSetName
+	}
+	for _, pattern := range c.namePatterns {
+		if matched, _ := regexp.MatchString(pattern, view.StackName); matched {
+			return "Pattern:" + pattern
+		}
+	}
+	return ""
+}
+
+func (c *TemplateVersionsCmd) majorityHash(views []*CfnTemplateVersionsView) string {
+	counts := map[string]int{}
+	newest := map[string]string{}
+	for _, view := range views {
+		counts[view.TemplateHash]++
+		if view.LastUpdatedTime > newest[view.TemplateHash] {
+			newest[view.TemplateHash] = view.LastUpdatedTime
+		}
+	}
+	majorityHash := ""
+	for hash, count := range counts {
+		if majorityHash == "" || count > counts[majorityHash] ||
+			(count == counts[majorityHash] && newest[hash] > newest[majorityHash]) ||
+			(count == counts[majorityHash] && newest[hash] == newest[majorityHash] && hash < majorityHash) {
+			majorityHash = hash
+		}
+	}
+	return majorityHash
+}
+
+func (c *TemplateVersionsCmd) GetGlobalViews() []*CfnTemplateVersionsView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, c.collected)
+
+	sortViews(globalViews, func(view *CfnTemplateVersionsView) []string {
+		return []string{view.AccountId, view.Region, view.StackName}
+	})
+
+	return globalViews
+}
+
+func (c *TemplateVersionsCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnTemplateVersionsView {
+	views := []*CfnTemplateVersionsView{}
+	c.logger.Info("get cfn template versions", "accountId", accountConfig.Id, "region", region)
+
+	cfn := cloudformation.New(newSession(accountConfig, region))
+
+	matchedStacks, err := describeMatchedStacks(cfn, accountConfig.Filters)
+	if err != nil {
+		return append(views, &CfnTemplateVersionsView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Error:       err,
+		})
+	}
+
+	for _, matchedStack := range matchedStacks {
+		view := &CfnTemplateVersionsView{
+			AccountId:    accountConfig.Id,
+			AccountName:  accountConfig.Name,
+			Region:       region,
+			StackName:    *matchedStack.StackName,
+			StackSetName: stackSetNameOf(*matchedStack.StackName),
+		}
+		lastUpdatedTime := matchedStack.LastUpdatedTime
+		if lastUpdatedTime == nil {
+			lastUpdatedTime = matchedStack.CreationTime
+		}
+		if lastUpdatedTime != nil {
+			utc := lastUpdatedTime.UTC()
+			view.LastUpdatedTime = formatTime(&utc)
+		}
+		view.TemplateHash, view.Error = getTemplateFingerprint(cfn, *matchedStack.StackId)
+		if view.Error != nil {
+			c.logger.Warn(fmt.Sprintf("failed to get template of cfn stack: %s", *matchedStack.StackName), "error", view.Error)
+		}
+		views = append(views, view)
+	}
+
+	return views
+}
+
+// stackSetNameOf returns the stack set name if the stack is a stack set instance, otherwise an empty string.
+func stackSetNameOf(stackName string) string {
+	matches := stackSetInstanceStackNameRegex.FindStringSubmatch(stackName)
+	if matches == nil {
+		return ""
+	}
+	return matches[1]
+}
+
+// getTemplateFingerprint returns the fingerprint of the original template of the stack.
+func getTemplateFingerprint(cfn cloudformationiface.CloudFormationAPI, stackId string) (string, error) {
+	body, err := getTemplateBody(cfn, stackId, cloudformation.TemplateStageOriginal)
+	if err != nil {
+		return "", err
+	}
+	return templateFingerprint(body), nil
+}
+
+// templateCache caches original templates of stacks, so that subcommands run together
+// such as by the all subcommand get the template of each stack only once.
+// methods of a nil cache just get templates.
+type templateCache struct {
+	mu        sync.Mutex
+	templates map[string]cachedTemplate
+}
+
+type cachedTemplate struct {
+	body string
+	err  error
+}
+
+func newTemplateCache() *templateCache {
+	return &templateCache{templates: map[string]cachedTemplate{}}
+}
+
+// getTemplateBody returns the original template of the stack, getting it at the first call for the stack.
+func (t *templateCache) getTemplateBody(cfn cloudformationiface.CloudFormationAPI, stackId string) (string, error) {
+	if t == nil {
+		return getTemplateBody(cfn, stackId, cloudformation.TemplateStageOriginal)
+	}
+	t.mu.Lock()
+	cached, ok := t.templates[stackId]
+	t.mu.Unlock()
+	if ok {
+		return cached.body, cached.err
+	}
+
+	body, err := getTemplateBody(cfn, stackId, cloudformation.TemplateStageOriginal)
+	t.mu.Lock()
+	t.templates[stackId] = cachedTemplate{body: body, err: err}
+	t.mu.Unlock()
+	return body, err
+}
+
+// getOptionalTemplateBody returns the original template of the stack for views which work without templates.
+// if it fails e.g. without cloudformation:GetTemplate permission, the error is logged at debug level
+// and an empty string is returned instead of failing the views.
+func (t *templateCache) getOptionalTemplateBody(cfn cloudformationiface.CloudFormationAPI, stackId string, logger *slog.Logger) string {
+	body, err := t.getTemplateBody(cfn, stackId)
+	if err != nil {
+		logger.Debug("failed to get template", "stackId", stackId, "error", err.Error())
+		return ""
+	}
+	return body
+}
+
+// optionalTemplateFingerprint returns the fingerprint of the template, or an empty string if there is no template.
+func optionalTemplateFingerprint(body string) string {
+	if body == "" {
+		return ""
+	}
+	return templateFingerprint(body)
+}
+
+// templateFingerprint returns the hash of the normalized template, so that templates differing only
+// in formats or key orders have the same fingerprint. unparsable templates are hashed as they are.
+func templateFingerprint(body string) string {
+	template, err := parseTemplate(body)
+	if err != nil {
+		return templateHash(body)
+	}
+	// maps are marshaled with sorted keys
+	return templateHash(compactJson(template))
+}
@@ -0,0 +1,168 @@
+package subcommands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	cfnConfig "github.com/horietakehiro/cfn-global-views/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateVersions_invalid(t *testing.T) {
+	c := getInvalidConfig(t, "")
+
+	cmd := TemplateVersionsCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 1, len(views))
+	assert.NotNil(t, views[0].Error, views[0])
+	versions := cmd.GetVersions(views)
+	assert.Equal(t, 1, len(versions))
+	assert.Equal(t, 0, versions[0].StackCount)
+	assert.Equal(t, views[0].Error.Error(), versions[0].Error)
+
+}
+
+func TestTemplateVersions_templateFingerprint(t *testing.T) {
+	assert.Equal(t, templateFingerprint(TEST_YAML_TEMPLATE), templateFingerprint(TEST_JSON_TEMPLATE))
+	assert.NotEqual(t, templateFingerprint(TEST_YAML_TEMPLATE), templateFingerprint("Resources: {}"))
+	assert.Equal(t, templateHash("{not a template"), templateFingerprint("{not a template"))
+}
+
+func TestTemplateVersions_versions_and_skews(t *testing.T) {
+	const (
+		stackSetStackName = "StackSet-baseline-0a1b2c3d-0a1b-0a1b-0a1b-0a1b2c3d4e5f"
+	)
+	assert.Equal(t, "baseline", stackSetNameOf(stackSetStackName))
+	assert.Equal(t, "", stackSetNameOf("app-dev"))
+
+	views := []*CfnTemplateVersionsView{
+		{AccountId: "1", Region: "r1", StackName: stackSetStackName, StackSetName: "baseline", TemplateHash: "new", LastUpdatedTime: "2023-02-03T00:00:00Z"},
+		{AccountId: "2", Region: "r1", StackName: stackSetStackName, StackSetName: "baseline", TemplateHash: "old", LastUpdatedTime: "2023-01-01T00:00:00Z"},
+		{AccountId: "3", Region: "r1", StackName: stackSetStackName, StackSetName: "baseline", TemplateHash: "new", LastUpdatedTime: "2023-02-01T00:00:00Z"},
+		{AccountId: "1", Region: "r1", StackName: "app-dev", TemplateHash: "app-old", LastUpdatedTime: "2023-01-01T00:00:00Z"},
+		{AccountId: "1", Region: "r1", StackName: "app-prd", TemplateHash: "app-new", LastUpdatedTime: "2023-02-01T00:00:00Z"},
+		{AccountId: "1", Region: "r1", StackName: "other", TemplateHash: "other", LastUpdatedTime: "2023-02-01T00:00:00Z"},
+	}
+
+	cmd := TemplateVersionsCmd{namePatterns: []string{"^app-"}}
+	versions := cmd.GetVersions(views)
+	assert.Equal(t, 5, len(versions))
+	assert.Equal(t, "new", versions[0].TemplateHash)
+	assert.Equal(t, 2, versions[0].StackCount)
+	assert.Equal(t, "2023-02-03T00:00:00Z", versions[0].NewestLastUpdatedTime)
+
+	skews := cmd.GetSkews(views)
+	assert.Equal(t, 5, len(skews))
+	// ties are broken by the newest update
+	assert.Equal(t, "Pattern:^app-", skews[0].Group)
+	assert.Equal(t, "app-new", skews[0].MajorityHash)
+	assert.True(t, skews[0].Outdated)
+	assert.False(t, skews[1].Outdated)
+	assert.Equal(t, "StackSet:baseline", skews[2].Group)
+	assert.Equal(t, "new", skews[2].MajorityHash)
+	assert.True(t, skews[3].Outdated)
+	assert.Equal(t, "2", skews[3].AccountId)
+}
+
+func TestTemplateVersions_versions_and_skews_errors(t *testing.T) {
+	views := []*CfnTemplateVersionsView{
+		{AccountId: "1", Region: "r1", StackName: "app-dev", Error: fmt.Errorf("access denied")},
+		{AccountId: "1", Region: "r1", StackName: "app-prd", TemplateHash: "app-new", LastUpdatedTime: "2023-02-01T00:00:00Z"},
+		{AccountId: "2", Region: "r1", Error: fmt.Errorf("invalid region")},
+	}
+
+	cmd := TemplateVersionsCmd{namePatterns: []string{"^app-"}}
+	versions := cmd.GetVersions(views)
+	assert.Equal(t, 3, len(versions))
+	assert.Equal(t, "app-new", versions[0].TemplateHash)
+	assert.Equal(t, "", versions[0].Error)
+	assert.Equal(t, []string{"1/r1/app-dev"}, versions[1].Stacks)
+	assert.Equal(t, 1, versions[1].StackCount)
+	assert.Equal(t, "access denied", versions[1].Error)
+	assert.Equal(t, []string{"2/r1"}, versions[2].Stacks)
+	assert.Equal(t, 0, versions[2].StackCount)
+
+	// errors don't count for the majority
+	skews := cmd.GetSkews(views)
+	assert.Equal(t, 3, len(skews))
+	assert.Equal(t, "app-new", skews[0].MajorityHash)
+	assert.False(t, skews[0].Outdated)
+	assert.Equal(t, "Pattern:^app-", skews[1].Group)
+	assert.Equal(t, "app-dev", skews[1].StackName)
+	assert.Equal(t, "access denied", skews[1].Error)
+	assert.False(t, skews[1].Outdated)
+	assert.Equal(t, "invalid region", skews[2].Error)
+
+	csvViews := cmd.toSkewCsvViews(skews)
+	assert.Equal(t, "access denied", csvViews[1].Error)
+}
+
+func TestTemplateVersions_writeGlobalViews_ndjson(t *testing.T) {
+	tmpNdjsonPath := "tmp.ndjson"
+	defer func() { os.Remove(tmpNdjsonPath) }()
+
+	c := &cfnConfig.CfnGlobalViewsConfig{AccountConfigs: []cfnConfig.AccountConfig{
+		{Id: "111111111111", Filters: cfnConfig.Filters{Regions: []string{"ap-northeast-1", "us-east-1"}}},
+	}}
+	cmd := TemplateVersionsCmd{}
+	getGlobalViews := func() []*CfnTemplateVersionsView {
+		return collectGlobalViews(c, true, func(accountConfig cfnConfig.AccountConfig, region string) []*CfnTemplateVersionsView {
+			return []*CfnTemplateVersionsView{{AccountId: accountConfig.Id, Region: region, StackName: "app", TemplateHash: "hash"}}
+		}, cmd.collected)
+	}
+
+	// versions aggregate all accounts and regions, so they are written once
+	err := writeGlobalViews("ndjson", tmpNdjsonPath, &cmd.collected, getGlobalViews, cmd.Report)
+	assert.Nil(t, err)
+	content, _ := os.ReadFile(tmpNdjsonPath)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"TemplateHash":"hash","StackCount":"2"`)
+	assert.Contains(t, lines[1], `"RecordType":"summary","Rows":1`)
+}
+
+type mockCountingTemplateClient struct {
+	cloudformationiface.CloudFormationAPI
+	calls int
+	err   error
+}
+
+func (m *mockCountingTemplateClient) GetTemplate(input *cloudformation.GetTemplateInput) (*cloudformation.GetTemplateOutput, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &cloudformation.GetTemplateOutput{TemplateBody: aws.String(`{"Resources": {}}`)}, nil
+}
+
+func TestTemplateVersions_templateCache(t *testing.T) {
+	cfn := &mockCountingTemplateClient{}
+	templates := newTemplateCache()
+	for i := 0; i < 3; i++ {
+		body := templates.getOptionalTemplateBody(cfn, "stack-a", TEST_LOGGER)
+		assert.Equal(t, `{"Resources": {}}`, body)
+	}
+	assert.Equal(t, 1, cfn.calls)
+
+	// nil caches get templates every time
+	var noCache *templateCache
+	noCache.getOptionalTemplateBody(cfn, "stack-a", TEST_LOGGER)
+	assert.Equal(t, 2, cfn.calls)
+
+	// failures leave template hashes empty instead of failing views
+	deniedCfn := &mockCountingTemplateClient{err: fmt.Errorf("AccessDenied")}
+	body := templates.getOptionalTemplateBody(deniedCfn, "stack-b", TEST_LOGGER)
+	assert.Equal(t, "", body)
+	assert.Equal(t, "", optionalTemplateFingerprint(body))
+	templates.getOptionalTemplateBody(deniedCfn, "stack-b", TEST_LOGGER)
+	assert.Equal(t, 1, deniedCfn.calls)
+}
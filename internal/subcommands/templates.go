@@ -0,0 +1,269 @@
+package subcommands
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/google/subcommands"
+	"golang.org/x/exp/slog"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+const (
+	TEMPLATE_FORMAT_JSON = "json"
+	TEMPLATE_FORMAT_YAML = "yaml"
+)
+
+type CfnTemplate struct {
+	Stage  string
+	Format string
+	// Path is relative to the output directory
+	Path string
+	Hash string
+}
+
+type CfnTemplatesView struct {
+	AccountId   string
+	AccountName string
+	Region      string
+	StackName   string
+	// RootStackName is the name of the matched root stack if the stack is a nested stack
+	RootStackName string
+	Templates     []CfnTemplate
+	Error         error
+}
+
+type CfnTemplatesCsvView struct {
+	AccountId      string
+	AccountName    string
+	Region         string
+	StackName      string
+	RootStackName  string
+	TemplateStage  string
+	TemplateFormat string
+	TemplatePath   string
+	TemplateHash   string
+	Error          string
+}
+
+type TemplatesCmd struct {
+	subcommands.Command
+	configFilePath string
+	outDirPath     string
+	format         string
+	verbose        bool
+	logger         *slog.Logger
+	config         *config.CfnGlobalViewsConfig
+}
+
+func (*TemplatesCmd) Name() string {
+	return "templates"
+}
+func (*TemplatesCmd) Synopsis() string {
+	return "download deployed cfn templates into a local directory tree with a manifest"
+}
+func (*TemplatesCmd) Usage() string {
+	return "templates -c path/to/config.yaml -o path/to/output/dir"
+}
+func (c *TemplatesCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
+	f.StringVar(&c.outDirPath, "o", "", "path to output directory. templates are saved as <dir>/<account id>/<region>/<stack name>.yaml|json")
+	f.StringVar(&c.format, "f", "csv", "manifest data format [csv, json] (default is csv). the manifest is saved as <dir>/manifest.csv|json")
+	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+}
+
+func (c *TemplatesCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outDirPath, []string{"csv", "json"}, c.verbose, c.validate)
+	if err != nil {
+		fmt.Println(err.Error())
+		return subcommands.ExitFailure
+	}
+
+	// the manifest is written even if no stacks match
+	err = os.MkdirAll(c.outDirPath, 0755)
+	if err != nil {
+		return exitStatus(c.logger, err)
+	}
+
+	globalViews := c.GetGlobalViews()
+
+	manifestPath := filepath.Join(c.outDirPath, "manifest."+c.format)
+	return exitStatus(c.logger, writeReport(c.format, manifestPath, &output.Report{Tables: []*output.Table{
+		newTable(c.toCsvViews(globalViews)).toOutput("manifest", globalViews),
+	}}))
+}
+
+// validate checks '-o' arg, which is required as the output directory.
+func (c *TemplatesCmd) validate() error {
+	if c.outDirPath == "" {
+		return fmt.Errorf("arg '-o path/to/output/dir' is required")
+	}
+	return nil
+}
+
+func (c *TemplatesCmd) toCsvViews(views []*CfnTemplatesView) []CfnTemplatesCsvView {
+	csvViews := []CfnTemplatesCsvView{}
+
+	for _, view := range views {
+		base := CfnTemplatesCsvView{
+			AccountId:     view.AccountId,
+			AccountName:   view.AccountName,
+			Region:        view.Region,
+			StackName:     view.StackName,
+			RootStackName: view.RootStackName,
+			Error:         errorString(view.Error),
+		}
+		if len(view.Templates) == 0 {
+			csvViews = append(csvViews, base)
+		}
+		for _, template := range view.Templates {
+			row := base
+			row.TemplateStage = template.Stage
+			row.TemplateFormat = template.Format
+			row.TemplatePath = template.Path
+			row.TemplateHash = template.Hash
+			csvViews = append(csvViews, row)
+		}
+	}
+
+	return csvViews
+}
+
+func (c *TemplatesCmd) GetGlobalViews() []*CfnTemplatesView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, nil)
+
+	sortViews(globalViews, func(view *CfnTemplatesView) []string {
+		return []string{view.AccountId, view.Region, view.StackName}
+	})
+
+	return globalViews
+}
+
+// getViews saves templates of the matched stacks and all nested stacks under them,
+// even if the nested stacks themselves don't match the filters.
+func (c *TemplatesCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnTemplatesView {
+	views := []*CfnTemplatesView{}
+	c.logger.Info("get cfn templates", "accountId", accountConfig.Id, "region", region)
+
+	cfn := cloudformation.New(newSession(accountConfig, region))
+
+	stacks, err := describeMatchedStacks(cfn, config.Filters{})
+	if err != nil {
+		return append(views, &CfnTemplatesView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			Error:       err,
+		})
+	}
+
+	for _, stack := range matchedStacksWithNested(stacks, accountConfig.Filters) {
+		view := &CfnTemplatesView{
+			AccountId:   accountConfig.Id,
+			AccountName: accountConfig.Name,
+			Region:      region,
+			StackName:   *stack.StackName,
+		}
+		if stack.RootId != nil {
+			view.RootStackName = stackNameFromId(*stack.RootId)
+		}
+		view.Templates, view.Error = c.saveTemplates(cfn, stack, filepath.Join(accountConfig.Id, region))
+		views = append(views, view)
+	}
+
+	return views
+}
+
+// saveTemplates saves the original template of the stack, and the processed template as <stack name>.processed.json
+// or <stack name>.processed.yaml following its format, only if it differs from the original one, e.g. the template has transforms.
+func (c *TemplatesCmd) saveTemplates(cfn cloudformationiface.CloudFormationAPI, stack *cloudformation.Stack, dir string) ([]CfnTemplate, error) {
+	templates := []CfnTemplate{}
+
+	original, err := getTemplateBody(cfn, *stack.StackId, cloudformation.TemplateStageOriginal)
+	if err != nil {
+		return templates, err
+	}
+	processed, err := getTemplateBody(cfn, *stack.StackId, cloudformation.TemplateStageProcessed)
+	if err != nil {
+		return templates, err
+	}
+
+	bodies := []struct{ stage, suffix, body string }{
+		{cloudformation.TemplateStageOriginal, "", original},
+	}
+	if processed != original {
+		bodies = append(bodies, struct{ stage, suffix, body string }{cloudformation.TemplateStageProcessed, ".processed", processed})
+	}
+
+	if err := os.MkdirAll(filepath.Join(c.outDirPath, dir), 0755); err != nil {
+		return templates, err
+	}
+	for _, b := range bodies {
+		format := templateFormat(b.body)
+		template := CfnTemplate{
+			Stage:  b.stage,
+			Format: format,
+			Path:   filepath.Join(dir, *stack.StackName+b.suffix+"."+format),
+			Hash:   templateHash(b.body),
+		}
+		if err := os.WriteFile(filepath.Join(c.outDirPath, template.Path), []byte(b.body), 0644); err != nil {
+			return templates, err
+		}
+		templates = append(templates, template)
+	}
+	return templates, nil
+}
+
+// matchedStacksWithNested returns stacks matching the filters and nested stacks whose root stacks match the filters.
+func matchedStacksWithNested(stacks []*cloudformation.Stack, filters config.Filters) []*cloudformation.Stack {
+	matchedStackIds := map[string]bool{}
+	for _, stack := range stacks {
+		if matchesFilters(stack, filters) {
+			matchedStackIds[*stack.StackId] = true
+		}
+	}
+	matchedStacks := []*cloudformation.Stack{}
+	for _, stack := range stacks {
+		if matchedStackIds[*stack.StackId] || (stack.RootId != nil && matchedStackIds[*stack.RootId]) {
+			matchedStacks = append(matchedStacks, stack)
+		}
+	}
+	return matchedStacks
+}
+
+func getTemplateBody(cfn cloudformationiface.CloudFormationAPI, stackId, stage string) (string, error) {
+	getTemplateOutput, err := cfn.GetTemplate(&cloudformation.GetTemplateInput{
+		StackName:     aws.String(stackId),
+		TemplateStage: aws.String(stage),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(getTemplateOutput.TemplateBody), nil
+}
+
+// templateFormat returns json if the template body is a json object, otherwise yaml.
+func templateFormat(body string) string {
+	if strings.HasPrefix(strings.TrimSpace(body), "{") {
+		return TEMPLATE_FORMAT_JSON
+	}
+	return TEMPLATE_FORMAT_YAML
+}
+
+func templateHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
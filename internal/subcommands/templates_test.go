@@ -0,0 +1,86 @@
+package subcommands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/google/subcommands"
+	cfnConfig "github.com/horietakehiro/cfn-global-views/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTemplatesClient struct {
+	cloudformationiface.CloudFormationAPI
+	original  string
+	processed string
+}
+
+func (m *mockTemplatesClient) GetTemplate(input *cloudformation.GetTemplateInput) (*cloudformation.GetTemplateOutput, error) {
+	if *input.TemplateStage == cloudformation.TemplateStageProcessed {
+		return &cloudformation.GetTemplateOutput{TemplateBody: aws.String(m.processed)}, nil
+	}
+	return &cloudformation.GetTemplateOutput{TemplateBody: aws.String(m.original)}, nil
+}
+
+func TestTemplates_invalid(t *testing.T) {
+	c := getInvalidConfig(t, "")
+
+	cmd := TemplatesCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 1, len(views))
+	assert.NotNil(t, views[0].Error, views[0])
+
+	// the manifest is written even if no templates are saved
+	dir := filepath.Join(t.TempDir(), "templates")
+	cmd = TemplatesCmd{
+		configFilePath: TMP_CONFIG_PATH,
+		outDirPath:     dir,
+		format:         "csv",
+	}
+	assert.Equal(t, subcommands.ExitSuccess, cmd.Execute(context.Background(), nil))
+	assert.FileExists(t, filepath.Join(dir, "manifest.csv"))
+
+}
+
+func TestTemplates_saveTemplates(t *testing.T) {
+	outDirPath := t.TempDir()
+	cmd := TemplatesCmd{outDirPath: outDirPath}
+	stack := &cloudformation.Stack{StackName: aws.String("stack"), StackId: aws.String("stack-id")}
+
+	// the processed template is saved only if it differs from the original one
+	cfn := &mockTemplatesClient{original: "Resources: {}\n", processed: "Resources: {}\n"}
+	templates, err := cmd.saveTemplates(cfn, stack, filepath.Join("123456789012", "ap-northeast-1"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(templates))
+	assert.Equal(t, filepath.Join("123456789012", "ap-northeast-1", "stack.yaml"), templates[0].Path)
+	assert.Equal(t, templateHash("Resources: {}\n"), templates[0].Hash)
+
+	cfn = &mockTemplatesClient{original: "Transform: AWS::Serverless-2016-10-31\n", processed: `{"Resources": {}}`}
+	templates, err = cmd.saveTemplates(cfn, stack, filepath.Join("123456789012", "ap-northeast-1"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(templates))
+	assert.Equal(t, TEMPLATE_FORMAT_JSON, templates[1].Format)
+	body, err := os.ReadFile(filepath.Join(outDirPath, "123456789012", "ap-northeast-1", "stack.processed.json"))
+	assert.Nil(t, err)
+	assert.Equal(t, `{"Resources": {}}`, string(body))
+}
+
+func TestTemplates_matchedStacksWithNested(t *testing.T) {
+	stacks := []*cloudformation.Stack{
+		{StackName: aws.String("root"), StackId: aws.String("root-id")},
+		{StackName: aws.String("root-Nested"), StackId: aws.String("nested-id"), ParentId: aws.String("root-id"), RootId: aws.String("root-id")},
+		{StackName: aws.String("other"), StackId: aws.String("other-id")},
+	}
+	matchedStacks := matchedStacksWithNested(stacks, cfnConfig.Filters{StackNameRegex: "^root$"})
+	assert.Equal(t, 2, len(matchedStacks))
+	assert.Equal(t, "root-Nested", *matchedStacks[1].StackName)
+}
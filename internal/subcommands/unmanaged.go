@@ -0,0 +1,224 @@
+package subcommands
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudcontrolapi"
+	"github.com/aws/aws-sdk-go/service/cloudcontrolapi/cloudcontrolapiiface"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/google/subcommands"
+	"golang.org/x/exp/slog"
+
+	"github.com/horietakehiro/cfn-global-views/config"
+	"github.com/horietakehiro/cfn-global-views/output"
+)
+
+type CfnUnmanagedResourceView struct {
+	AccountId   string
+	AccountName string
+	Region      string
+	Type        string
+	Identifier  string
+	// Tags are from the Tags property of the resource got by Cloud Control API, if the type has it
+	Tags  []CfnTag
+	Error error
+}
+
+type CfnUnmanagedResourceCsvView struct {
+	AccountId          string
+	AccountName        string
+	Region             string
+	ResourceType       string
+	ResourceIdentifier string
+	ResourceTags       string
+	Error              string
+}
+
+type UnmanagedCmd struct {
+	subcommands.Command
+	configFilePath string
+	outFilePath    string
+	format         string
+	verbose        bool
+	logger         *slog.Logger
+	config         *config.CfnGlobalViewsConfig
+	collected      func(views []*CfnUnmanagedResourceView)
+}
+
+func (*UnmanagedCmd) Name() string {
+	return "unmanaged"
+}
+func (*UnmanagedCmd) Synopsis() string {
+	return "list resources of configured types which no cfn stack owns"
+}
+func (*UnmanagedCmd) Usage() string {
+	return "unmanaged -c path/to/config.yaml"
+}
+func (c *UnmanagedCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.configFilePath, "c", "", "path to config yaml file")
+	f.StringVar(&c.outFilePath, "o", "", "path to output file path. if you dont't set, just stdout result")
+	f.StringVar(&c.format, "f", "csv", formatUsage("csv"))
+	f.BoolVar(&c.verbose, "v", false, "if set, stdout debug log messages")
+}
+
+func (c *UnmanagedCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	var err error
+
+	c.logger, c.config, err = setupCmd(c.configFilePath, c.format, c.outFilePath, output.Formats(), c.verbose, nil)
+	if err != nil {
+		fmt.Println(err.Error())
+		return subcommands.ExitFailure
+	}
+	if len(c.config.UnmanagedResourceTypes) == 0 {
+		fmt.Println("must specify UnmanagedResourceTypes in config file")
+		return subcommands.ExitFailure
+	}
+
+	return exitStatus(c.logger, writeGlobalViews(c.format, c.outFilePath, &c.collected, c.GetGlobalViews, c.Report))
+}
+
+func (c *UnmanagedCmd) toCsvViews(views []*CfnUnmanagedResourceView) []CfnUnmanagedResourceCsvView {
+	csvViews := []CfnUnmanagedResourceCsvView{}
+	for _, view := range views {
+		csvViews = append(csvViews, CfnUnmanagedResourceCsvView{
+			AccountId:          view.AccountId,
+			AccountName:        view.AccountName,
+			Region:             view.Region,
+			ResourceType:       view.Type,
+			ResourceIdentifier: view.Identifier,
+			ResourceTags:       formatTags(view.Tags),
+			Error:              errorString(view.Error),
+		})
+	}
+	return csvViews
+}
+
+func (c *UnmanagedCmd) Report(views []*CfnUnmanagedResourceView) *output.Report {
+	return &output.Report{Tables: []*output.Table{newTable(c.toCsvViews(views)).toOutput(c.Name(), views)}}
+}
+
+func (c *UnmanagedCmd) GetGlobalViews() []*CfnUnmanagedResourceView {
+	globalViews := collectGlobalViews(c.config, c.verbose, c.getViews, c.collected)
+
+	sortViews(globalViews, func(view *CfnUnmanagedResourceView) []string {
+		return []string{view.AccountId, view.Region, view.Type, view.Identifier}
+	})
+
+	return globalViews
+}
+
+func (c *UnmanagedCmd) getViews(accountConfig config.AccountConfig, region string) []*CfnUnmanagedResourceView {
+	c.logger.Info("get unmanaged resources", "accountId", accountConfig.Id, "region", region)
+
+	sess := newSession(accountConfig, region)
+	views := findUnmanagedResources(cloudformation.New(sess), cloudcontrolapi.New(sess), c.config.UnmanagedResourceTypes)
+	for _, view := range views {
+		view.AccountId = accountConfig.Id
+		view.AccountName = accountConfig.Name
+		view.Region = region
+	}
+	return views
+}
+
+// findUnmanagedResources lists resources of the types at the clients' account and region,
+// and returns those whose identifiers are not physical ids of any stack resources.
+// all stacks are considered regardless of stack filters, since resources owned by
+// stacks out of the filters are still managed.
+// stacks whose resources failed to be listed are reported as error views, and their resources may be listed as unmanaged.
+func findUnmanagedResources(cfn cloudformationiface.CloudFormationAPI, cc cloudcontrolapiiface.CloudControlApiAPI, resourceTypes []string) []*CfnUnmanagedResourceView {
+	views := []*CfnUnmanagedResourceView{}
+
+	physicalIds, stackErrors, err := listManagedPhysicalIds(cfn)
+	if err != nil {
+		return append(views, &CfnUnmanagedResourceView{Error: err})
+	}
+	for _, stackError := range stackErrors {
+		views = append(views, &CfnUnmanagedResourceView{Error: stackError})
+	}
+
+	for _, resourceType := range resourceTypes {
+		input := &cloudcontrolapi.ListResourcesInput{TypeName: aws.String(resourceType)}
+		for {
+			listResourcesOutput, err := cc.ListResources(input)
+			if err != nil {
+				views = append(views, &CfnUnmanagedResourceView{Type: resourceType, Error: err})
+				break
+			}
+			for _, resource := range listResourcesOutput.ResourceDescriptions {
+				identifier := aws.StringValue(resource.Identifier)
+				if physicalIds[identifier] {
+					continue
+				}
+				views = append(views, getUnmanagedResource(cc, resourceType, identifier))
+			}
+			if listResourcesOutput.NextToken == nil {
+				break
+			}
+			input.NextToken = listResourcesOutput.NextToken
+		}
+	}
+	return views
+}
+
+// getUnmanagedResource returns the unmanaged resource with tags got by Cloud Control API GetResource,
+// since properties listed by ListResources usually have only the identifier.
+// if GetResource fails, the resource is still listed with the error.
+func getUnmanagedResource(cc cloudcontrolapiiface.CloudControlApiAPI, resourceType, identifier string) *CfnUnmanagedResourceView {
+	view := &CfnUnmanagedResourceView{Type: resourceType, Identifier: identifier}
+	getResourceOutput, err := cc.GetResource(&cloudcontrolapi.GetResourceInput{
+		TypeName:   aws.String(resourceType),
+		Identifier: aws.String(identifier),
+	})
+	if err != nil {
+		view.Error = err
+		return view
+	}
+	if description := getResourceOutput.ResourceDescription; description != nil {
+		view.Tags = propertyTags(aws.StringValue(description.Properties))
+	}
+	return view
+}
+
+// listManagedPhysicalIds returns physical ids of resources of all stacks at the client's account and region,
+// and errors of stacks whose resources failed to be listed.
+// note that identifiers of Cloud Control API match physical ids only for types whose primary identifier is the physical id.
+// resources of types with composite identifiers, e.g. "<api id>|<stage name>", are always listed as unmanaged.
+func listManagedPhysicalIds(cfn cloudformationiface.CloudFormationAPI) (map[string]bool, []error, error) {
+	physicalIds := map[string]bool{}
+	stackErrors := []error{}
+
+	stacks, err := describeMatchedStacks(cfn, config.Filters{})
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, stack := range stacks {
+		stackResources, err := listStackResources(cfn, aws.StringValue(stack.StackId))
+		if err != nil {
+			stackErrors = append(stackErrors, fmt.Errorf("failed to list resources of stack %s: %w", aws.StringValue(stack.StackName), err))
+			continue
+		}
+		for _, resource := range stackResources {
+			if id := aws.StringValue(resource.PhysicalResourceId); id != "" {
+				physicalIds[id] = true
+			}
+		}
+	}
+	return physicalIds, stackErrors, nil
+}
+
+// propertyTags returns the Tags property of resource properties returned by Cloud Control API GetResource.
+// it returns nil if the properties don't have tags as a list of keys and values.
+func propertyTags(properties string) []CfnTag {
+	var p struct {
+		Tags []CfnTag
+	}
+	if properties == "" || json.Unmarshal([]byte(properties), &p) != nil {
+		return nil
+	}
+	return p.Tags
+}
@@ -0,0 +1,157 @@
+package subcommands
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudcontrolapi"
+	"github.com/aws/aws-sdk-go/service/cloudcontrolapi/cloudcontrolapiiface"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockUnmanagedCfnClient struct {
+	cloudformationiface.CloudFormationAPI
+	failedStackId string
+}
+
+func (m *mockUnmanagedCfnClient) DescribeStacks(input *cloudformation.DescribeStacksInput) (*cloudformation.DescribeStacksOutput, error) {
+	return &cloudformation.DescribeStacksOutput{Stacks: []*cloudformation.Stack{
+		{StackName: aws.String("app"), StackId: aws.String("app-id")},
+		{StackName: aws.String("network"), StackId: aws.String("network-id")},
+	}}, nil
+}
+
+func (m *mockUnmanagedCfnClient) ListStackResources(input *cloudformation.ListStackResourcesInput) (*cloudformation.ListStackResourcesOutput, error) {
+	if *input.StackName == m.failedStackId {
+		return nil, fmt.Errorf("AccessDenied")
+	}
+	if *input.StackName == "app-id" && input.NextToken == nil {
+		return &cloudformation.ListStackResourcesOutput{
+			StackResourceSummaries: []*cloudformation.StackResourceSummary{
+				{PhysicalResourceId: aws.String("app-bucket")},
+			},
+			NextToken: aws.String("next"),
+		}, nil
+	}
+	if *input.StackName == "app-id" {
+		return &cloudformation.ListStackResourcesOutput{
+			StackResourceSummaries: []*cloudformation.StackResourceSummary{
+				{PhysicalResourceId: aws.String("sg-app")},
+				// resources being created don't have physical ids yet
+				{LogicalResourceId: aws.String("Queue")},
+			},
+		}, nil
+	}
+	return &cloudformation.ListStackResourcesOutput{
+		StackResourceSummaries: []*cloudformation.StackResourceSummary{
+			{PhysicalResourceId: aws.String("sg-network")},
+		},
+	}, nil
+}
+
+type mockListResourcesClient struct {
+	cloudcontrolapiiface.CloudControlApiAPI
+}
+
+func (m *mockListResourcesClient) ListResources(input *cloudcontrolapi.ListResourcesInput) (*cloudcontrolapi.ListResourcesOutput, error) {
+	switch *input.TypeName {
+	case "AWS::S3::Bucket":
+		if input.NextToken == nil {
+			return &cloudcontrolapi.ListResourcesOutput{
+				ResourceDescriptions: []*cloudcontrolapi.ResourceDescription{
+					{Identifier: aws.String("app-bucket"), Properties: aws.String(`{"BucketName":"app-bucket"}`)},
+					{Identifier: aws.String("deleted-bucket"), Properties: aws.String(`{"BucketName":"deleted-bucket"}`)},
+				},
+				NextToken: aws.String("next"),
+			}, nil
+		}
+		return &cloudcontrolapi.ListResourcesOutput{
+			ResourceDescriptions: []*cloudcontrolapi.ResourceDescription{
+				// ListResources usually returns only the identifier as properties
+				{Identifier: aws.String("manual-bucket"), Properties: aws.String(`{"BucketName":"manual-bucket"}`)},
+			},
+		}, nil
+	case "AWS::EC2::SecurityGroup":
+		return &cloudcontrolapi.ListResourcesOutput{
+			ResourceDescriptions: []*cloudcontrolapi.ResourceDescription{
+				{Identifier: aws.String("sg-app")},
+				{Identifier: aws.String("sg-network")},
+				{Identifier: aws.String("sg-manual"), Properties: aws.String(`{"GroupId":"sg-manual"}`)},
+			},
+		}, nil
+	}
+	return nil, fmt.Errorf("TypeNotFoundException")
+}
+
+func (m *mockListResourcesClient) GetResource(input *cloudcontrolapi.GetResourceInput) (*cloudcontrolapi.GetResourceOutput, error) {
+	properties := map[string]string{
+		"manual-bucket": `{"BucketName":"manual-bucket","Tags":[{"Key":"Owner","Value":"someone"}]}`,
+		"sg-manual":     `{"GroupId":"sg-manual","Tags":{"Owner":"someone"}}`,
+	}
+	p, ok := properties[*input.Identifier]
+	if !ok {
+		return nil, fmt.Errorf("ResourceNotFoundException")
+	}
+	return &cloudcontrolapi.GetResourceOutput{
+		TypeName:            input.TypeName,
+		ResourceDescription: &cloudcontrolapi.ResourceDescription{Identifier: input.Identifier, Properties: aws.String(p)},
+	}, nil
+}
+
+func TestUnmanaged_findUnmanagedResources(t *testing.T) {
+	views := findUnmanagedResources(
+		&mockUnmanagedCfnClient{}, &mockListResourcesClient{},
+		[]string{"AWS::S3::Bucket", "AWS::EC2::SecurityGroup", "AWS::Not::Exist"},
+	)
+
+	assert.Equal(t, 4, len(views))
+	// resources failed to be got are still listed with the error
+	assert.Equal(t, "deleted-bucket", views[0].Identifier)
+	assert.EqualError(t, views[0].Error, "ResourceNotFoundException")
+	// tags are from GetResource, not from identifier only properties of ListResources
+	assert.Equal(t, "manual-bucket", views[1].Identifier)
+	assert.Equal(t, []CfnTag{{Key: "Owner", Value: "someone"}}, views[1].Tags)
+	assert.Nil(t, views[1].Error)
+	assert.Equal(t, "AWS::EC2::SecurityGroup", views[2].Type)
+	assert.Equal(t, "sg-manual", views[2].Identifier)
+	// tags not as a list of keys and values are ignored
+	assert.Nil(t, views[2].Tags)
+	assert.Equal(t, "AWS::Not::Exist", views[3].Type)
+	assert.NotNil(t, views[3].Error)
+
+	csvViews := (&UnmanagedCmd{}).toCsvViews(views)
+	assert.Equal(t, "Owner=someone", csvViews[1].ResourceTags)
+	assert.Equal(t, "TypeNotFoundException", csvViews[3].Error)
+}
+
+func TestUnmanaged_findUnmanagedResources_stack_error(t *testing.T) {
+	views := findUnmanagedResources(
+		&mockUnmanagedCfnClient{failedStackId: "network-id"}, &mockListResourcesClient{},
+		[]string{"AWS::EC2::SecurityGroup"},
+	)
+
+	// the failed stack is reported, and other stacks are still considered
+	assert.Equal(t, 3, len(views))
+	assert.EqualError(t, views[0].Error, "failed to list resources of stack network: AccessDenied")
+	assert.Equal(t, "sg-network", views[1].Identifier)
+	assert.Equal(t, "sg-manual", views[2].Identifier)
+}
+
+func TestUnmanaged_invalid(t *testing.T) {
+	c := getInvalidConfig(t, `
+UnmanagedResourceTypes:
+  - AWS::S3::Bucket
+`)
+
+	cmd := UnmanagedCmd{
+		config: c,
+		logger: TEST_LOGGER,
+	}
+
+	views := cmd.GetGlobalViews()
+	assert.Equal(t, 1, len(views))
+	assert.NotNil(t, views[0].Error, views[0])
+}
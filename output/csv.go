@@ -0,0 +1,45 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+)
+
+// CsvWriter writes the main tables as csv. several tables are separated by empty lines.
+type CsvWriter struct{}
+
+func (*CsvWriter) RequiresFile() bool { return false }
+
+func (*CsvWriter) Write(outFilePath string, report *Report) error {
+	writer, err := OpenWriter(outFilePath)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	csvWriter := csv.NewWriter(writer)
+	for i, t := range report.MainTables() {
+		if i != 0 {
+			csvWriter.Flush()
+			if _, err = writer.Write([]byte("\n")); err != nil {
+				return err
+			}
+		}
+		err = csvWriter.Write(t.Header)
+		if err != nil {
+			return err
+		}
+		for _, record := range t.Records {
+			values := []string{}
+			for _, v := range record {
+				values = append(values, fmt.Sprint(v))
+			}
+			err = csvWriter.Write(values)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
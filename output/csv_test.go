@@ -0,0 +1,28 @@
+package output
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCsvWriter_Write(t *testing.T) {
+	tmpCsvPath := "tmp.csv"
+	defer func() { os.Remove(tmpCsvPath) }()
+
+	report := &Report{Tables: []*Table{
+		{Name: "first", Header: []string{"Name", "Count"}, Records: [][]interface{}{{"a", 1}, {"b,c", 2}}},
+		{Name: "second", Header: []string{"Name"}, Records: [][]interface{}{}},
+	}}
+	err := (&CsvWriter{}).Write(tmpCsvPath, report)
+	assert.Nil(t, err)
+	content, _ := os.ReadFile(tmpCsvPath)
+	assert.Equal(t, "Name,Count\na,1\n\"b,c\",2\n", string(content))
+
+	report.Main = ALL_TABLES
+	err = (&CsvWriter{}).Write(tmpCsvPath, report)
+	assert.Nil(t, err)
+	content, _ = os.ReadFile(tmpCsvPath)
+	assert.Equal(t, "Name,Count\na,1\n\"b,c\",2\n\nName\n", string(content))
+}
@@ -0,0 +1,187 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ExcelWriter writes each table of the report as an excel table at the sheet named after the table.
+// if the output file already exists, the sheets are added to (or replaced in) the file.
+type ExcelWriter struct{}
+
+func (*ExcelWriter) RequiresFile() bool { return true }
+
+func (*ExcelWriter) Write(outFilePath string, report *Report) error {
+	for _, t := range report.Tables {
+		err := writeTable(outFilePath, t)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTable(outFilePath string, t *Table) error {
+	sheetName := t.Name
+	var file *excelize.File
+	var err error
+	if _, statErr := os.Stat(outFilePath); statErr == nil {
+		file, err = excelize.OpenFile(outFilePath, excelize.Options{})
+		if err != nil {
+			return err
+		}
+	} else {
+		file = excelize.NewFile()
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	_ = file.DeleteSheet(sheetName)
+	index, err := file.NewSheet(sheetName)
+	if err != nil {
+		return err
+	}
+	file.SetActiveSheet(index)
+
+	numFields := len(t.Header)
+	startCol := 2
+	endCol := startCol + numFields - 1
+	startRow := 2
+	// a table needs at least one data row
+	endRow := startRow + len(t.Records)
+	if len(t.Records) == 0 {
+		endRow++
+	}
+	startCell, _ := excelize.CoordinatesToCellName(startCol, startRow)
+	endCell, _ := excelize.CoordinatesToCellName(endCol, endRow)
+
+	// create table
+	disable := false
+	err = file.AddTable(sheetName, fmt.Sprintf("%s:%s", startCell, endCell), &excelize.TableOptions{
+		Name:              strings.ReplaceAll(sheetName, "-", "_"),
+		StyleName:         "TableStyleMedium2",
+		ShowFirstColumn:   true,
+		ShowLastColumn:    true,
+		ShowRowStripes:    &disable,
+		ShowColumnStripes: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	// write table column names and cell values
+	for i, name := range t.Header {
+		cell, _ := excelize.CoordinatesToCellName(startCol+i, startRow)
+		file.SetCellValue(sheetName, cell, name)
+	}
+	for r, record := range t.Records {
+		for i, v := range record {
+			cell, _ := excelize.CoordinatesToCellName(startCol+i, startRow+1+r)
+			file.SetCellValue(sheetName, cell, v)
+		}
+	}
+
+	// set styles
+	style, err := file.NewStyle(&excelize.Style{
+		Alignment: &excelize.Alignment{
+			ShrinkToFit:     true,
+			Horizontal:      "left",
+			JustifyLastLine: true,
+			WrapText:        true,
+			Vertical:        "center",
+		},
+	})
+	if err != nil {
+		return err
+	}
+	err = file.SetCellStyle(sheetName, startCell, endCell, style)
+	if err != nil {
+		return err
+	}
+	if len(t.Highlights) != 0 {
+		highlightStyle, err := file.NewStyle(&excelize.Style{
+			Alignment: &excelize.Alignment{
+				ShrinkToFit:     true,
+				Horizontal:      "left",
+				JustifyLastLine: true,
+				WrapText:        true,
+				Vertical:        "center",
+			},
+			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFEB9C"}},
+		})
+		if err != nil {
+			return err
+		}
+		for cell := range t.Highlights {
+			cellName, _ := excelize.CoordinatesToCellName(startCol+cell[1], startRow+1+cell[0])
+			err = file.SetCellStyle(sheetName, cellName, cellName, highlightStyle)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if len(t.Links) != 0 {
+		linkStyle, err := file.NewStyle(&excelize.Style{
+			Alignment: &excelize.Alignment{
+				ShrinkToFit:     true,
+				Horizontal:      "left",
+				JustifyLastLine: true,
+				WrapText:        true,
+				Vertical:        "center",
+			},
+			Font: &excelize.Font{Color: "0563C1", Underline: "single"},
+		})
+		if err != nil {
+			return err
+		}
+		for i, name := range t.Header {
+			if !t.Links[name] {
+				continue
+			}
+			for r, record := range t.Records {
+				url, ok := record[i].(string)
+				if !ok || url == "" {
+					continue
+				}
+				cellName, _ := excelize.CoordinatesToCellName(startCol+i, startRow+1+r)
+				err = file.SetCellHyperLink(sheetName, cellName, url, "External")
+				if err != nil {
+					return err
+				}
+				err = file.SetCellStyle(sheetName, cellName, cellName, linkStyle)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for i, name := range t.Header {
+		maxLength := 0
+		for _, record := range t.Records {
+			if l := len(fmt.Sprint(record[i])); l > maxLength {
+				maxLength = l
+			}
+		}
+		var colWidth int
+		if maxLength >= 50 {
+			colWidth = 50
+		} else if maxLength <= len(name) {
+			colWidth = len(name) + 5
+		} else {
+			colWidth = maxLength
+		}
+		colName, _ := excelize.ColumnNumberToName(startCol + i)
+		err = file.SetColWidth(sheetName, colName, colName, float64(colWidth))
+		if err != nil {
+			return err
+		}
+	}
+
+	return file.SaveAs(outFilePath)
+}
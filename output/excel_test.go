@@ -0,0 +1,75 @@
+package output
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestExcelWriter_Write(t *testing.T) {
+	tmpExcelPath := "tmp.xlsx"
+	defer func() { os.Remove(tmpExcelPath) }()
+
+	report := &Report{Tables: []*Table{
+		{Name: "first-sheet", Header: []string{"Name", "Count"}, Records: [][]interface{}{{"a", 1}, {"b", 2}}},
+		{Name: "second-sheet", Header: []string{"Name", "Count"}, Records: [][]interface{}{}},
+	}}
+	err := (&ExcelWriter{}).Write(tmpExcelPath, report)
+	assert.Nil(t, err)
+
+	file, err := excelize.OpenFile(tmpExcelPath)
+	assert.Nil(t, err)
+	defer file.Close()
+	assert.Contains(t, file.GetSheetList(), "first-sheet")
+	assert.Contains(t, file.GetSheetList(), "second-sheet")
+	value, err := file.GetCellValue("first-sheet", "C4")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", value)
+}
+
+func TestExcelWriter_highlights(t *testing.T) {
+	tmpExcelPath := "tmp.xlsx"
+	defer func() { os.Remove(tmpExcelPath) }()
+
+	report := &Report{Tables: []*Table{{
+		Name:       "highlights",
+		Header:     []string{"StackName", "Tag:ENV", "Value"},
+		Records:    [][]interface{}{{"a", "dev", "x"}, {"b", "prd", "y"}},
+		Highlights: map[[2]int]bool{{1, 2}: true},
+	}}}
+	err := (&ExcelWriter{}).Write(tmpExcelPath, report)
+	assert.Nil(t, err)
+
+	file, err := excelize.OpenFile(tmpExcelPath)
+	assert.Nil(t, err)
+	defer file.Close()
+	highlighted, _ := file.GetCellStyle("highlights", "D4")
+	normal, _ := file.GetCellStyle("highlights", "D3")
+	assert.NotEqual(t, normal, highlighted)
+}
+
+func TestExcelWriter_links(t *testing.T) {
+	tmpExcelPath := "tmp.xlsx"
+	defer func() { os.Remove(tmpExcelPath) }()
+
+	report := &Report{Tables: []*Table{{
+		Name:    "links",
+		Header:  []string{"StackName", "ConsoleUrl"},
+		Records: [][]interface{}{{"a", "https://example.com/a"}, {"b", ""}},
+		Links:   map[string]bool{"ConsoleUrl": true},
+	}}}
+	err := (&ExcelWriter{}).Write(tmpExcelPath, report)
+	assert.Nil(t, err)
+
+	file, err := excelize.OpenFile(tmpExcelPath)
+	assert.Nil(t, err)
+	defer file.Close()
+	linked, target, _ := file.GetCellHyperLink("links", "C3")
+	assert.True(t, linked)
+	assert.Equal(t, "https://example.com/a", target)
+	// empty urls are not linked
+	linked, _, _ = file.GetCellHyperLink("links", "C4")
+	assert.False(t, linked)
+}
@@ -0,0 +1,99 @@
+package output
+
+import (
+	"fmt"
+)
+
+const (
+	ACCOUNT_ID_COLUMN   = "AccountId"
+	ACCOUNT_NAME_COLUMN = "AccountName"
+	REGION_COLUMN       = "Region"
+	STACK_NAME_COLUMN   = "StackName"
+	ERROR_COLUMN        = "Error"
+)
+
+// columnIndex returns the index of the column named name, or -1 if the table doesn't have it.
+func (t *Table) columnIndex(name string) int {
+	for i, column := range t.Header {
+		if column == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// value returns the string value of the record at the column named name, or empty string.
+func (t *Table) value(record []interface{}, name string) string {
+	i := t.columnIndex(name)
+	if i < 0 {
+		return ""
+	}
+	return fmt.Sprint(record[i])
+}
+
+// recordGroup is records of a table sharing values of the grouping columns, in the original order.
+type recordGroup struct {
+	Key     string
+	Records []int
+}
+
+// groupRecords groups record indexes of the table by the key, keeping the order of the first appearances.
+func (t *Table) groupRecords(records []int, key func(record []interface{}) string) []*recordGroup {
+	groups := []*recordGroup{}
+	index := map[string]*recordGroup{}
+	for _, r := range records {
+		k := key(t.Records[r])
+		group, ok := index[k]
+		if !ok {
+			group = &recordGroup{Key: k}
+			index[k] = group
+			groups = append(groups, group)
+		}
+		group.Records = append(group.Records, r)
+	}
+	return groups
+}
+
+// accountRegion returns the account and region of the record e.g. "111111111111 (dev) / ap-northeast-1".
+func (t *Table) accountRegion(record []interface{}) string {
+	account := t.value(record, ACCOUNT_ID_COLUMN)
+	if name := t.value(record, ACCOUNT_NAME_COLUMN); name != "" {
+		account = fmt.Sprintf("%s (%s)", account, name)
+	}
+	return fmt.Sprintf("%s / %s", account, t.value(record, REGION_COLUMN))
+}
+
+// TableSummary is counts of a table shown at headers of document formats and the ndjson summary.
+type TableSummary struct {
+	Name     string
+	Rows     int
+	Accounts int
+	Regions  int
+	Stacks   int
+	Errors   int
+}
+
+func (t *Table) summarize() TableSummary {
+	accounts := map[string]bool{}
+	regions := map[string]bool{}
+	stacks := map[string]bool{}
+	summary := TableSummary{Name: t.Name, Rows: len(t.Records)}
+	for _, record := range t.Records {
+		if account := t.value(record, ACCOUNT_ID_COLUMN); account != "" {
+			accounts[account] = true
+		}
+		if region := t.value(record, REGION_COLUMN); region != "" {
+			regions[region] = true
+		}
+		if stack := t.value(record, STACK_NAME_COLUMN); stack != "" {
+			stacks[t.accountRegion(record)+"/"+stack] = true
+		}
+		if t.value(record, ERROR_COLUMN) != "" {
+			summary.Errors++
+		}
+	}
+	summary.Accounts = len(accounts)
+	summary.Regions = len(regions)
+	summary.Stacks = len(stacks)
+	return summary
+}
@@ -0,0 +1,164 @@
+package output
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// HtmlWriter writes the main tables as a standalone html document with a summary header.
+// tables can be sorted by clicking column names and filtered by text at the browser.
+type HtmlWriter struct{}
+
+func (*HtmlWriter) RequiresFile() bool { return false }
+
+func (*HtmlWriter) Write(outFilePath string, report *Report) error {
+	writer, err := OpenWriter(outFilePath)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	document := htmlDocument{GeneratedAt: time.Now().UTC().Format(time.RFC3339)}
+	for _, t := range report.MainTables() {
+		document.Summaries = append(document.Summaries, t.summarize())
+		document.Tables = append(document.Tables, newHtmlTable(t))
+	}
+	return htmlTemplate.Execute(writer, document)
+}
+
+type htmlDocument struct {
+	GeneratedAt string
+	Summaries   []TableSummary
+	Tables      []htmlTable
+}
+
+type htmlTable struct {
+	Name    string
+	Header  []string
+	Records [][]htmlCell
+}
+
+type htmlCell struct {
+	Value string
+	Link  bool
+	Class string
+}
+
+func newHtmlTable(t *Table) htmlTable {
+	table := htmlTable{Name: t.Name, Header: t.Header}
+	for r, record := range t.Records {
+		cells := []htmlCell{}
+		for i, v := range record {
+			value := fmt.Sprint(v)
+			classes := []string{}
+			if strings.HasSuffix(t.Header[i], "Status") {
+				if class := statusClass(value); class != "" {
+					classes = append(classes, class)
+				}
+			}
+			if t.Header[i] == ERROR_COLUMN && value != "" {
+				classes = append(classes, "status-failed")
+			}
+			if t.Highlights[[2]int{r, i}] {
+				classes = append(classes, "highlight")
+			}
+			cells = append(cells, htmlCell{
+				Value: value,
+				Link:  t.Links[t.Header[i]] && value != "",
+				Class: strings.Join(classes, " "),
+			})
+		}
+		table.Records = append(table.Records, cells)
+	}
+	return table
+}
+
+// statusClass returns the css class coloring statuses of stacks, resources, drifts and operations.
+func statusClass(status string) string {
+	switch {
+	case status == "":
+		return ""
+	case strings.Contains(status, "FAILED"), status == "DRIFTED", status == "DELETED", status == "MODIFIED":
+		return "status-failed"
+	case strings.Contains(status, "ROLLBACK"):
+		return "status-rollback"
+	case strings.HasSuffix(status, "IN_PROGRESS"), status == "RUNNING", status == "QUEUED", status == "PENDING":
+		return "status-in-progress"
+	case strings.HasSuffix(status, "COMPLETE"), status == "IN_SYNC", status == "SUCCEEDED", status == "CURRENT":
+		return "status-complete"
+	}
+	return ""
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>cfn-global-views</title>
+<style>
+body { font-family: sans-serif; font-size: 13px; margin: 16px; }
+table { border-collapse: collapse; margin-bottom: 24px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; white-space: pre-wrap; }
+th { background: #1f4e78; color: #fff; }
+.sortable th { cursor: pointer; }
+.sortable th.asc::after { content: " \25B2"; }
+.sortable th.desc::after { content: " \25BC"; }
+.filter { margin-bottom: 8px; width: 320px; }
+.highlight { background: #ffeb9c; }
+.status-failed { background: #ffc7ce; color: #9c0006; }
+.status-rollback { background: #fcd5b4; color: #974706; }
+.status-in-progress { background: #ffeb9c; color: #9c5700; }
+.status-complete { background: #c6efce; color: #006100; }
+</style>
+</head>
+<body>
+<h1>cfn-global-views</h1>
+<p>generated at {{.GeneratedAt}}</p>
+<table>
+<tr><th>Table</th><th>Rows</th><th>Accounts</th><th>Regions</th><th>Stacks</th><th>Errors</th></tr>
+{{- range .Summaries}}
+<tr><td><a href="#{{.Name}}">{{.Name}}</a></td><td>{{.Rows}}</td><td>{{.Accounts}}</td><td>{{.Regions}}</td><td>{{.Stacks}}</td><td{{if .Errors}} class="status-failed"{{end}}>{{.Errors}}</td></tr>
+{{- end}}
+</table>
+{{- range .Tables}}
+<h2 id="{{.Name}}">{{.Name}}</h2>
+<input class="filter" type="search" placeholder="filter rows">
+<table class="sortable">
+<thead><tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{- range .Records}}
+<tr>{{range .}}<td{{if .Class}} class="{{.Class}}"{{end}}>{{if .Link}}<a href="{{.Value}}">{{.Value}}</a>{{else}}{{.Value}}{{end}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+<script>
+document.querySelectorAll("table.sortable").forEach(function (table) {
+  var filter = table.previousElementSibling;
+  var rows = function () { return Array.prototype.slice.call(table.tBodies[0].rows); };
+  filter.addEventListener("input", function () {
+    var text = filter.value.toLowerCase();
+    rows().forEach(function (row) {
+      row.style.display = row.textContent.toLowerCase().indexOf(text) < 0 ? "none" : "";
+    });
+  });
+  table.querySelectorAll("th").forEach(function (th, i) {
+    th.addEventListener("click", function () {
+      var asc = !th.classList.contains("asc");
+      table.querySelectorAll("th").forEach(function (h) { h.classList.remove("asc", "desc"); });
+      th.classList.add(asc ? "asc" : "desc");
+      var sorted = rows().sort(function (a, b) {
+        var x = a.cells[i].textContent, y = b.cells[i].textContent;
+        var c = (x !== "" && y !== "" && !isNaN(x) && !isNaN(y)) ? x - y : x.localeCompare(y);
+        return asc ? c : -c;
+      });
+      sorted.forEach(function (row) { table.tBodies[0].appendChild(row); });
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
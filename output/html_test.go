@@ -0,0 +1,40 @@
+package output
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHtmlWriter_Write(t *testing.T) {
+	tmpHtmlPath := "tmp.html"
+	defer func() { os.Remove(tmpHtmlPath) }()
+
+	report := &Report{Tables: []*Table{{
+		Name:   "stacks",
+		Header: []string{"AccountId", "Region", "StackName", "StackStatus", "StackConsoleUrl", "Error"},
+		Records: [][]interface{}{
+			{"111111111111", "ap-northeast-1", "<a>", "UPDATE_ROLLBACK_COMPLETE", "https://example.com/?a=1&b=2", ""},
+			{"111111111111", "us-east-1", "", "", "", "denied"},
+		},
+		Links: map[string]bool{"StackConsoleUrl": true},
+	}}}
+	err := (&HtmlWriter{}).Write(tmpHtmlPath, report)
+	assert.Nil(t, err)
+	content, _ := os.ReadFile(tmpHtmlPath)
+	assert.Contains(t, string(content), `<tr><td><a href="#stacks">stacks</a></td><td>2</td><td>1</td><td>2</td><td>1</td><td class="status-failed">1</td></tr>`)
+	assert.Contains(t, string(content), `<td>&lt;a&gt;</td><td class="status-rollback">UPDATE_ROLLBACK_COMPLETE</td><td><a href="https://example.com/?a=1&amp;b=2">`)
+	assert.Contains(t, string(content), `<td class="status-failed">denied</td>`)
+}
+
+func TestHtmlWriter_statusClass(t *testing.T) {
+	assert.Equal(t, "status-failed", statusClass("UPDATE_FAILED"))
+	assert.Equal(t, "status-failed", statusClass("DRIFTED"))
+	assert.Equal(t, "status-rollback", statusClass("ROLLBACK_COMPLETE"))
+	assert.Equal(t, "status-in-progress", statusClass("UPDATE_IN_PROGRESS"))
+	assert.Equal(t, "status-complete", statusClass("CREATE_COMPLETE"))
+	assert.Equal(t, "status-complete", statusClass("IN_SYNC"))
+	assert.Equal(t, "", statusClass("NOT_CHECKED"))
+	assert.Equal(t, "", statusClass(""))
+}
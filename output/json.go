@@ -0,0 +1,39 @@
+package output
+
+import (
+	"encoding/json"
+)
+
+// JsonWriter writes data of the main table as json. data of all tables are written
+// as an object keyed by table names if Report.Main is ALL_TABLES.
+type JsonWriter struct{}
+
+func (*JsonWriter) RequiresFile() bool { return false }
+
+func (*JsonWriter) Write(outFilePath string, report *Report) error {
+	if report.Main != ALL_TABLES {
+		return WriteJson(outFilePath, report.Tables[report.Main].Data)
+	}
+	data := map[string]interface{}{}
+	for _, t := range report.Tables {
+		data[t.Name] = t.Data
+	}
+	return WriteJson(outFilePath, data)
+}
+
+// WriteJson writes v as json to outFilePath or stdout.
+func WriteJson(outFilePath string, v interface{}) error {
+	jsonViews, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	writer, err := OpenWriter(outFilePath)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	_, err = writer.Write(jsonViews)
+	return err
+}
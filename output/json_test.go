@@ -0,0 +1,31 @@
+package output
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonWriter_Write(t *testing.T) {
+	tmpJsonPath := "tmp.json"
+	defer func() { os.Remove(tmpJsonPath) }()
+
+	type row struct {
+		Name string
+	}
+	report := &Report{Tables: []*Table{
+		{Name: "first", Data: []row{{Name: "a"}}},
+		{Name: "second", Data: []row{}},
+	}, Main: 1}
+	err := (&JsonWriter{}).Write(tmpJsonPath, report)
+	assert.Nil(t, err)
+	content, _ := os.ReadFile(tmpJsonPath)
+	assert.Equal(t, `[]`, string(content))
+
+	report.Main = ALL_TABLES
+	err = (&JsonWriter{}).Write(tmpJsonPath, report)
+	assert.Nil(t, err)
+	content, _ = os.ReadFile(tmpJsonPath)
+	assert.Equal(t, `{"first":[{"Name":"a"}],"second":[]}`, string(content))
+}
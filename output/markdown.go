@@ -0,0 +1,119 @@
+package output
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// MarkdownWriter writes the main tables as github flavored markdown tables.
+// records are grouped by account and region, and by stack in collapsible sections,
+// if the table has the columns.
+type MarkdownWriter struct{}
+
+func (*MarkdownWriter) RequiresFile() bool { return false }
+
+func (*MarkdownWriter) Write(outFilePath string, report *Report) error {
+	writer, err := OpenWriter(outFilePath)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	b := &strings.Builder{}
+	for _, t := range report.MainTables() {
+		writeMarkdownTable(b, t)
+	}
+	_, err = io.WriteString(writer, b.String())
+	return err
+}
+
+func writeMarkdownTable(b *strings.Builder, t *Table) {
+	summary := t.summarize()
+	fmt.Fprintf(b, "# %s\n\n", t.Name)
+	fmt.Fprintf(b, "%d rows, %d accounts, %d regions, %d stacks, %d errors\n\n",
+		summary.Rows, summary.Accounts, summary.Regions, summary.Stacks, summary.Errors)
+
+	records := make([]int, len(t.Records))
+	for r := range t.Records {
+		records[r] = r
+	}
+	if t.columnIndex(ACCOUNT_ID_COLUMN) < 0 || t.columnIndex(REGION_COLUMN) < 0 {
+		writeMarkdownRecords(b, t, records, nil)
+		return
+	}
+
+	grouped := map[string]bool{ACCOUNT_ID_COLUMN: true, ACCOUNT_NAME_COLUMN: true, REGION_COLUMN: true}
+	for _, group := range t.groupRecords(records, t.accountRegion) {
+		fmt.Fprintf(b, "## %s\n\n", escapeMarkdown(group.Key))
+		if t.columnIndex(STACK_NAME_COLUMN) < 0 {
+			writeMarkdownRecords(b, t, group.Records, grouped)
+			continue
+		}
+		stackGrouped := map[string]bool{STACK_NAME_COLUMN: true}
+		for column := range grouped {
+			stackGrouped[column] = true
+		}
+		stackGroups := t.groupRecords(group.Records, func(record []interface{}) string {
+			return t.value(record, STACK_NAME_COLUMN)
+		})
+		for _, stackGroup := range stackGroups {
+			stackName := stackGroup.Key
+			if stackName == "" {
+				stackName = "(no stacks)"
+			}
+			fmt.Fprintf(b, "<details>\n<summary>%s (%d rows)</summary>\n\n", html.EscapeString(stackName), len(stackGroup.Records))
+			writeMarkdownRecords(b, t, stackGroup.Records, stackGrouped)
+			b.WriteString("</details>\n\n")
+		}
+	}
+}
+
+// writeMarkdownRecords writes the records as a markdown table, except for the excluded columns.
+func writeMarkdownRecords(b *strings.Builder, t *Table, records []int, excluded map[string]bool) {
+	columns := []int{}
+	for i, name := range t.Header {
+		if !excluded[name] {
+			columns = append(columns, i)
+		}
+	}
+
+	b.WriteString("|")
+	for _, i := range columns {
+		fmt.Fprintf(b, " %s |", escapeMarkdown(t.Header[i]))
+	}
+	b.WriteString("\n|")
+	for range columns {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	for _, r := range records {
+		b.WriteString("|")
+		for _, i := range columns {
+			value := escapeMarkdown(fmt.Sprint(t.Records[r][i]))
+			if t.Links[t.Header[i]] && value != "" {
+				value = fmt.Sprintf("[link](%s)", value)
+			}
+			if t.Highlights[[2]int{r, i}] && value != "" {
+				value = fmt.Sprintf("**%s**", value)
+			}
+			fmt.Fprintf(b, " %s |", value)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+}
+
+var markdownReplacer = strings.NewReplacer(
+	"|", "\\|",
+	"\r\n", "<br>",
+	"\n", "<br>",
+	"<", "&lt;",
+	">", "&gt;",
+)
+
+// escapeMarkdown escapes a value so that it stays in a cell of a markdown table.
+func escapeMarkdown(value string) string {
+	return markdownReplacer.Replace(value)
+}
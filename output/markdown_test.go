@@ -0,0 +1,68 @@
+package output
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdownWriter_Write(t *testing.T) {
+	tmpMarkdownPath := "tmp.md"
+	defer func() { os.Remove(tmpMarkdownPath) }()
+
+	report := &Report{Tables: []*Table{
+		{
+			Name:   "resources",
+			Header: []string{"AccountId", "AccountName", "Region", "StackName", "ResourceStatus", "Error"},
+			Records: [][]interface{}{
+				{"111111111111", "dev", "ap-northeast-1", "a", "CREATE_COMPLETE", ""},
+				{"111111111111", "dev", "us-east-1", "", "", "a|b\nc"},
+			},
+			Highlights: map[[2]int]bool{{0, 4}: true},
+		},
+		{
+			Name:    "resources-summary",
+			Header:  []string{"ResourceType", "Total"},
+			Records: [][]interface{}{{"AWS::S3::Bucket", 1}},
+		},
+	}, Main: ALL_TABLES}
+	err := (&MarkdownWriter{}).Write(tmpMarkdownPath, report)
+	assert.Nil(t, err)
+	content, _ := os.ReadFile(tmpMarkdownPath)
+	assert.Equal(t, `# resources
+
+2 rows, 1 accounts, 2 regions, 1 stacks, 1 errors
+
+## 111111111111 (dev) / ap-northeast-1
+
+<details>
+<summary>a (1 rows)</summary>
+
+| ResourceStatus | Error |
+| --- | --- |
+| **CREATE_COMPLETE** |  |
+
+</details>
+
+## 111111111111 (dev) / us-east-1
+
+<details>
+<summary>(no stacks) (1 rows)</summary>
+
+| ResourceStatus | Error |
+| --- | --- |
+|  | a\|b<br>c |
+
+</details>
+
+# resources-summary
+
+1 rows, 0 accounts, 0 regions, 0 stacks, 0 errors
+
+| ResourceType | Total |
+| --- | --- |
+| AWS::S3::Bucket | 1 |
+
+`, string(content))
+}
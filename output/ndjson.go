@@ -0,0 +1,158 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+const (
+	NDJSON_RECORD_TYPE_ROW     = "row"
+	NDJSON_RECORD_TYPE_SUMMARY = "summary"
+)
+
+// NdjsonWriter writes each record of the main tables as a json object per line,
+// and a summary object of counts and errors at the last line.
+// as a StreamWriter, records are written as soon as each account and region completes.
+type NdjsonWriter struct{}
+
+func (*NdjsonWriter) RequiresFile() bool { return false }
+
+func (w *NdjsonWriter) Write(outFilePath string, report *Report) error {
+	stream, err := w.OpenStream(outFilePath)
+	if err != nil {
+		return err
+	}
+	stream.WritePart(report)
+	return stream.Close()
+}
+
+func (*NdjsonWriter) OpenStream(outFilePath string) (Stream, error) {
+	writer, err := OpenWriter(outFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return &ndjsonStream{writer: writer, tables: map[string]*Table{}}, nil
+}
+
+// NdjsonSummary is the last line of ndjson outputs.
+type NdjsonSummary struct {
+	RecordType string
+	Rows       int
+	Tables     []TableSummary
+	Errors     []NdjsonError
+}
+
+// NdjsonError is an error reported at a row of the tables.
+type NdjsonError struct {
+	Table     string
+	AccountId string
+	Region    string
+	StackName string `json:",omitempty"`
+	Error     string
+}
+
+type ndjsonStream struct {
+	mu     sync.Mutex
+	writer io.WriteCloser
+	err    error
+	// tables accumulate records written so far for the summary, in the order of names
+	tables map[string]*Table
+	names  []string
+	errors []NdjsonError
+}
+
+func (s *ndjsonStream) WritePart(report *Report) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+
+	b := &bytes.Buffer{}
+	for _, t := range report.MainTables() {
+		table, ok := s.tables[t.Name]
+		if !ok {
+			table = &Table{Name: t.Name, Header: t.Header}
+			s.tables[t.Name] = table
+			s.names = append(s.names, t.Name)
+		}
+		for _, record := range t.Records {
+			line, err := ndjsonRow(t, record)
+			if err != nil {
+				s.err = err
+				return
+			}
+			b.Write(line)
+			b.WriteString("\n")
+			if errorString := t.value(record, ERROR_COLUMN); errorString != "" {
+				s.errors = append(s.errors, NdjsonError{
+					Table:     t.Name,
+					AccountId: t.value(record, ACCOUNT_ID_COLUMN),
+					Region:    t.value(record, REGION_COLUMN),
+					StackName: t.value(record, STACK_NAME_COLUMN),
+					Error:     errorString,
+				})
+			}
+		}
+		table.Records = append(table.Records, t.Records...)
+	}
+	_, s.err = s.writer.Write(b.Bytes())
+}
+
+func (s *ndjsonStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.writer.Close()
+	if s.err != nil {
+		return s.err
+	}
+
+	summary := NdjsonSummary{
+		RecordType: NDJSON_RECORD_TYPE_SUMMARY,
+		Tables:     []TableSummary{},
+		Errors:     s.errors,
+	}
+	if summary.Errors == nil {
+		summary.Errors = []NdjsonError{}
+	}
+	for _, name := range s.names {
+		table := s.tables[name].summarize()
+		summary.Rows += table.Rows
+		summary.Tables = append(summary.Tables, table)
+	}
+	line, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	_, err = s.writer.Write(append(line, '\n'))
+	return err
+}
+
+// ndjsonRow marshals the record as a json object keyed by the header, in the order of columns.
+func ndjsonRow(t *Table, record []interface{}) ([]byte, error) {
+	b := &bytes.Buffer{}
+	b.WriteString(`{"RecordType":"` + NDJSON_RECORD_TYPE_ROW + `","Table":`)
+	name, err := json.Marshal(t.Name)
+	if err != nil {
+		return nil, err
+	}
+	b.Write(name)
+	for i, column := range t.Header {
+		key, err := json.Marshal(column)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(record[i])
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(",")
+		b.Write(key)
+		b.WriteString(":")
+		b.Write(value)
+	}
+	b.WriteString("}")
+	return b.Bytes(), nil
+}
@@ -0,0 +1,50 @@
+package output
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNdjsonWriter_OpenStream(t *testing.T) {
+	tmpNdjsonPath := "tmp.ndjson"
+	defer func() { os.Remove(tmpNdjsonPath) }()
+
+	stream, err := (&NdjsonWriter{}).OpenStream(tmpNdjsonPath)
+	assert.Nil(t, err)
+	header := []string{"AccountId", "Region", "StackName", "Count", "Error"}
+	stream.WritePart(&Report{Tables: []*Table{
+		{Name: "stacks", Header: header, Records: [][]interface{}{{"111111111111", "ap-northeast-1", "a", 1, ""}}},
+	}})
+	stream.WritePart(&Report{Tables: []*Table{
+		{Name: "stacks", Header: header, Records: [][]interface{}{{"111111111111", "us-east-1", "", 0, "denied"}}},
+	}})
+	err = stream.Close()
+	assert.Nil(t, err)
+
+	content, _ := os.ReadFile(tmpNdjsonPath)
+	assert.Equal(t, strings.Join([]string{
+		`{"RecordType":"row","Table":"stacks","AccountId":"111111111111","Region":"ap-northeast-1","StackName":"a","Count":1,"Error":""}`,
+		`{"RecordType":"row","Table":"stacks","AccountId":"111111111111","Region":"us-east-1","StackName":"","Count":0,"Error":"denied"}`,
+		`{"RecordType":"summary","Rows":2,"Tables":[{"Name":"stacks","Rows":2,"Accounts":1,"Regions":2,"Stacks":1,"Errors":1}],"Errors":[{"Table":"stacks","AccountId":"111111111111","Region":"us-east-1","Error":"denied"}]}`,
+	}, "\n")+"\n", string(content))
+}
+
+func TestNdjsonWriter_Write(t *testing.T) {
+	tmpNdjsonPath := "tmp.ndjson"
+	defer func() { os.Remove(tmpNdjsonPath) }()
+
+	report := &Report{Tables: []*Table{
+		{Name: "resources", Header: []string{"Name"}, Records: [][]interface{}{{"a"}}},
+		{Name: "resources-summary", Header: []string{"Total"}, Records: [][]interface{}{{1}}},
+	}, Main: 1}
+	err := (&NdjsonWriter{}).Write(tmpNdjsonPath, report)
+	assert.Nil(t, err)
+
+	content, _ := os.ReadFile(tmpNdjsonPath)
+	assert.Equal(t, `{"RecordType":"row","Table":"resources-summary","Total":1}
+{"RecordType":"summary","Rows":1,"Tables":[{"Name":"resources-summary","Rows":1,"Accounts":0,"Regions":0,"Stacks":0,"Errors":0}],"Errors":[]}
+`, string(content))
+}
@@ -0,0 +1,130 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	// ALL_TABLES as Report.Main makes single table formats write all tables of the report
+	ALL_TABLES = -1
+)
+
+// Table is a header and records of a view, with the structured data it was made from.
+type Table struct {
+	// Name is used as the excel sheet name
+	Name    string
+	Header  []string
+	Records [][]interface{}
+	// Highlights are cells to be emphasized, keyed by record and column indexes
+	Highlights map[[2]int]bool
+	// Links are names of columns whose values are urls
+	Links map[string]bool
+	// Data is the structured view written by formats such as json
+	Data interface{}
+}
+
+// Report is tables output by a subcommand at once.
+type Report struct {
+	Tables []*Table
+	// Main is the index of the table written by single table formats such as csv and json,
+	// or ALL_TABLES to write all of them. formats such as excel always write all tables.
+	Main int
+	// Aggregated is true if the main tables aggregate views of all accounts and regions, such as summaries.
+	// reports of parts of views are not written to streams, since they would aggregate only the parts.
+	Aggregated bool
+}
+
+// MainTables returns tables to be written by single table formats.
+func (r *Report) MainTables() []*Table {
+	if r.Main == ALL_TABLES {
+		return r.Tables
+	}
+	return r.Tables[r.Main : r.Main+1]
+}
+
+// Writer writes reports in a format.
+type Writer interface {
+	// Write writes the report to outFilePath, or stdout if outFilePath is empty.
+	Write(outFilePath string, report *Report) error
+	// RequiresFile is true if the format can't be written to stdout.
+	RequiresFile() bool
+}
+
+// StreamWriter is a Writer which can also write parts of a report, as soon as views of
+// each account and region are collected.
+type StreamWriter interface {
+	Writer
+	// OpenStream opens outFilePath, or stdout if outFilePath is empty, to write parts of a report.
+	OpenStream(outFilePath string) (Stream, error)
+}
+
+// Stream writes parts of a report. it must be safe for concurrent use.
+type Stream interface {
+	// WritePart writes the main tables of a part of a report.
+	WritePart(report *Report)
+	// Close finishes the output, and returns the first error while writing parts if any.
+	Close() error
+}
+
+var (
+	mu      sync.RWMutex
+	writers = map[string]Writer{}
+	// formats in registration order
+	formats = []string{}
+)
+
+func init() {
+	Register("csv", &CsvWriter{})
+	Register("json", &JsonWriter{})
+	Register("excel", &ExcelWriter{})
+	Register("markdown", &MarkdownWriter{})
+	Register("html", &HtmlWriter{})
+	Register("ndjson", &NdjsonWriter{})
+}
+
+// Register makes the writer available as the format for '-f' args of all subcommands.
+// it panics if the format is already registered.
+func Register(format string, writer Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	if writer == nil {
+		panic("output: Register writer is nil")
+	}
+	if _, ok := writers[format]; ok {
+		panic(fmt.Sprintf("output: Register called twice for format %s", format))
+	}
+	writers[format] = writer
+	formats = append(formats, format)
+}
+
+// Lookup returns the writer registered as the format.
+func Lookup(format string) (Writer, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	writer, ok := writers[format]
+	return writer, ok
+}
+
+// Formats returns registered formats in registration order.
+func Formats() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]string{}, formats...)
+}
+
+// OpenWriter opens outFilePath, or stdout if outFilePath is empty.
+func OpenWriter(outFilePath string) (io.WriteCloser, error) {
+	if outFilePath == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(outFilePath)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
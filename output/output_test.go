@@ -0,0 +1,41 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testWriter struct{}
+
+func (*testWriter) RequiresFile() bool                             { return false }
+func (*testWriter) Write(outFilePath string, report *Report) error { return nil }
+
+func TestOutput_Register(t *testing.T) {
+	assert.Equal(t, []string{"csv", "json", "excel"}, Formats()[:3])
+
+	Register("test", &testWriter{})
+	writer, ok := Lookup("test")
+	assert.True(t, ok)
+	assert.Equal(t, &testWriter{}, writer)
+	assert.Equal(t, "test", Formats()[len(Formats())-1])
+
+	assert.Panics(t, func() { Register("test", &testWriter{}) })
+	assert.Panics(t, func() { Register("nil", nil) })
+
+	_, ok = Lookup("xml")
+	assert.False(t, ok)
+}
+
+func TestOutput_MainTables(t *testing.T) {
+	first := &Table{Name: "first"}
+	second := &Table{Name: "second"}
+	report := &Report{Tables: []*Table{first, second}}
+	assert.Equal(t, []*Table{first}, report.MainTables())
+
+	report.Main = 1
+	assert.Equal(t, []*Table{second}, report.MainTables())
+
+	report.Main = ALL_TABLES
+	assert.Equal(t, []*Table{first, second}, report.MainTables())
+}